@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// AzureMetadataEndpoint is the Azure Instance Metadata Service.
+const AzureMetadataEndpoint = "http://169.254.169.254"
+
+const azureMetadataAPIVersion = "2021-02-01"
+
+// azureInstance is the part of the IMDS instance document that discovery uses.
+type azureInstance struct {
+	Compute struct {
+		Location            string `json:"location"`
+		Zone                string `json:"zone"`
+		PlatformFaultDomain string `json:"platformFaultDomain"`
+		VMSize              string `json:"vmSize"`
+		VMID                string `json:"vmId"`
+		Name                string `json:"name"`
+		TagsList            []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"tagsList"`
+	} `json:"compute"`
+	Network struct {
+		Interface []struct {
+			IPv4 struct {
+				IPAddress []struct {
+					PrivateIPAddress string `json:"privateIpAddress"`
+					PublicIPAddress  string `json:"publicIpAddress"`
+				} `json:"ipAddress"`
+			} `json:"ipv4"`
+		} `json:"interface"`
+	} `json:"network"`
+}
+
+// DiscoverAzure reads the instance document of the Azure IMDS, every request needs the Metadata: true header.
+// The data center is the location and the rack is the availability zone, or the fault domain of VMs that are
+// not in a zone, named like AzureSnitch, i.e., eastus and rack-1.
+func DiscoverAzure(endpoint string, logger lg.Logger) (*InstanceMetadata, error) {
+	if endpoint == "" {
+		endpoint = AzureMetadataEndpoint
+	}
+	url := strings.TrimSuffix(endpoint, "/") + "/metadata/instance?api-version=" + azureMetadataAPIVersion
+	client := &http.Client{Timeout: DiscoveryTimeout}
+
+	document, err := metadataGet(client, url, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	logger.Debug("Azure metadata", document)
+	var instance azureInstance
+	if err := json.Unmarshal([]byte(document), &instance); err != nil {
+		return nil, fmt.Errorf("Unable to read the Azure instance metadata: %s", err)
+	}
+
+	compute := instance.Compute
+	metadata := &InstanceMetadata{
+		InstanceID:   compute.VMID,
+		InstanceType: compute.VMSize,
+		Hostname:     compute.Name,
+		Region:       compute.Location,
+		Zone:         compute.Zone,
+		Attributes:   make(map[string]string),
+	}
+	for _, tag := range compute.TagsList {
+		metadata.Attributes[tag.Name] = tag.Value
+	}
+	if len(instance.Network.Interface) > 0 && len(instance.Network.Interface[0].IPv4.IPAddress) > 0 {
+		address := instance.Network.Interface[0].IPv4.IPAddress[0]
+		metadata.PrivateAddress = address.PrivateIPAddress
+		metadata.PublicAddress = address.PublicIPAddress
+	}
+	if metadata.PrivateAddress == "" {
+		return nil, fmt.Errorf("Azure metadata has no private IP address")
+	}
+	if compute.Location == "" {
+		return nil, fmt.Errorf("Azure metadata has no location")
+	}
+
+	metadata.Datacenter = compute.Location
+	if compute.Zone != "" {
+		metadata.Rack = "rack-" + compute.Zone
+	} else if compute.PlatformFaultDomain != "" {
+		metadata.Rack = "rack-" + compute.PlatformFaultDomain
+	}
+	return metadata, nil
+}
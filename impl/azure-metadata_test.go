@@ -0,0 +1,63 @@
+package impl
+
+import (
+	"net/http"
+	"testing"
+)
+
+// azureMetadataServer serves the IMDS instance document, which needs the Metadata: true header and an api-version.
+func azureMetadataServer(t *testing.T, document string) string {
+	values := map[string]string{"instance": document}
+	return metadataServer(t, "/metadata/", values, func(writer http.ResponseWriter, request *http.Request) bool {
+		if request.Header.Get("Metadata") != "true" || request.URL.Query().Get("api-version") == "" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return false
+		}
+		return true
+	}).URL
+}
+
+func TestDiscoverAzureZone(t *testing.T) {
+	server := azureMetadataServer(t, `{
+  "compute": {"location": "eastus", "zone": "2", "platformFaultDomain": "0", "vmSize": "Standard_L8s_v2",
+    "vmId": "vm-1", "name": "cassandra-1", "tagsList": [{"name": "cassandra-seed", "value": "true"}]},
+  "network": {"interface": [{"ipv4": {"ipAddress": [{"privateIpAddress": "10.1.0.4", "publicIpAddress": "20.1.2.3"}]}}]}
+}`)
+	metadata, err := DiscoverAzure(server, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.PrivateAddress != "10.1.0.4" || metadata.PublicAddress != "20.1.2.3" {
+		t.Errorf("unexpected addresses %s and %s", metadata.PrivateAddress, metadata.PublicAddress)
+	}
+	if metadata.Datacenter != "eastus" || metadata.Rack != "rack-2" {
+		t.Errorf("expected data center eastus and rack rack-2, got %s and %s", metadata.Datacenter, metadata.Rack)
+	}
+	if metadata.InstanceType != "Standard_L8s_v2" || metadata.InstanceID != "vm-1" || metadata.Hostname != "cassandra-1" {
+		t.Errorf("unexpected instance %s, %s named %s", metadata.InstanceType, metadata.InstanceID, metadata.Hostname)
+	}
+	if metadata.Attributes["cassandra-seed"] != "true" {
+		t.Errorf("expected the cassandra-seed tag, got %v", metadata.Attributes)
+	}
+}
+
+func TestDiscoverAzureFaultDomain(t *testing.T) {
+	server := azureMetadataServer(t, `{
+  "compute": {"location": "westeurope", "zone": "", "platformFaultDomain": "1"},
+  "network": {"interface": [{"ipv4": {"ipAddress": [{"privateIpAddress": "10.1.0.5", "publicIpAddress": ""}]}}]}
+}`)
+	metadata, err := DiscoverAzure(server, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Datacenter != "westeurope" || metadata.Rack != "rack-1" {
+		t.Errorf("expected data center westeurope and rack rack-1, got %s and %s", metadata.Datacenter, metadata.Rack)
+	}
+}
+
+func TestDiscoverAzureWithoutAddress(t *testing.T) {
+	server := azureMetadataServer(t, `{"compute": {"location": "eastus"}, "network": {"interface": []}}`)
+	if _, err := DiscoverAzure(server, testLogger()); err == nil {
+		t.Error("expected an error without a private IP address")
+	}
+}
@@ -0,0 +1,124 @@
+package impl
+
+import (
+	"os"
+	"io/ioutil"
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+func initCassandraEnvTemplate(templateFileName string, logger lg.Logger) {
+	if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
+		logger.Debug("Cassandra env template does not exist so we are creating it", templateFileName)
+		err = ioutil.WriteFile(templateFileName, []byte(CassandraEnvTemplate), 0644)
+		if err != nil {
+			logger.ErrorError("Unable to write template file "+templateFileName, err)
+		}
+	}
+}
+
+// CassandraEnvTemplate replaces conf/cassandra-env.sh. The heap is sized by cassandra-cloud so the
+// calculate_heap_sizes function of the stock file is not needed. Like the stock file it reads jvm.options
+// for the single layout, the split layout files are read by bin/cassandra.
+const CassandraEnvTemplate = `
+# This file was generated with the template {{.CassandraEnvTemplate}} by cassandra-cloud.
+# It is sourced by bin/cassandra and bin/nodetool.
+
+{{if eq .JvmOptionsLayout "single"}}###############
+# JVM OPTIONS #
+###############
+
+# Cassandra 3.x only reads jvm.options here, so every option of the generated file is added to JVM_OPTS.
+JVM_OPTS_FILE="{{.JvmOptionsFileName}}"
+for opt in ` + "`" + `grep "^-" "$JVM_OPTS_FILE"` + "`" + `
+do
+    JVM_OPTS="$JVM_OPTS $opt"
+done
+
+{{end}}#################
+# HEAP SETTINGS #
+#################
+
+# Set by the cassandra-cloud heap ergonomics, these match -Xmx and -Xmn in the jvm options files.
+MAX_HEAP_SIZE="{{.MaxHeapSize.MB}}M"
+HEAP_NEWSIZE="{{.CmsYoungGenSize.MB}}M"
+
+# The jvm options files normally set the heap already so only add it when they do not.
+if ! echo "$JVM_OPTS" | grep -q -- "-Xmx"; then
+    JVM_OPTS="$JVM_OPTS -Xms${MAX_HEAP_SIZE}"
+    JVM_OPTS="$JVM_OPTS -Xmx${MAX_HEAP_SIZE}"
+fi
+{{if eq .GC "CMS"}}if ! echo "$JVM_OPTS" | grep -q -- "-Xmn"; then
+    JVM_OPTS="$JVM_OPTS -Xmn${HEAP_NEWSIZE}"
+fi
+{{end}}
+###########
+# LOGGING #
+###########
+
+CASSANDRA_LOG_DIR="{{.LogDir}}"
+export CASSANDRA_LOG_DIR
+if [ ! -d "$CASSANDRA_LOG_DIR" ]; then
+    mkdir -p "$CASSANDRA_LOG_DIR"
+fi
+
+# Heap dumps go to the log directory unless CASSANDRA_HEAPDUMP_DIR is set.
+if [ "x$CASSANDRA_HEAPDUMP_DIR" = "x" ]; then
+    CASSANDRA_HEAPDUMP_DIR="$CASSANDRA_LOG_DIR"
+fi
+JVM_OPTS="$JVM_OPTS -XX:HeapDumpPath=$CASSANDRA_HEAPDUMP_DIR/cassandra-$(date +%s)-pid$$.hprof"
+
+# Sets the path where logback and GC logs are written.
+JVM_OPTS="$JVM_OPTS -Dcassandra.logdir=$CASSANDRA_LOG_DIR"
+
+################
+# JVM SETTINGS #
+################
+
+# Specifies the default port over which Cassandra will be available for JMX connections.
+JMX_PORT="{{.JmxPort}}"
+
+# Set the hotspot compiler options and load the native libraries shipped with Cassandra.
+JVM_OPTS="$JVM_OPTS -XX:CompileCommandFile=$CASSANDRA_CONF/hotspot_compiler"
+JVM_OPTS="$JVM_OPTS -Djava.library.path=$CASSANDRA_HOME/lib/sigar-bin"
+
+# Use jemalloc for off heap memory if it is installed.
+if [ "x$CASSANDRA_LIBJEMALLOC" = "x" ]; then
+    for libjemalloc in /usr/lib64/libjemalloc.so* /usr/lib/x86_64-linux-gnu/libjemalloc.so* /usr/lib/libjemalloc.so*; do
+        if [ -f "$libjemalloc" ]; then
+            export LD_PRELOAD="$libjemalloc"
+            break
+        fi
+    done
+fi
+
+#######
+# JMX #
+#######
+
+{{if or (eq .JmxHost "localhost") (eq .JmxHost "127.0.0.1")}}# JMX only listens on localhost, use nodetool from this host.
+LOCAL_JMX=yes
+{{else}}# JMX listens on all interfaces and tells clients to connect to {{.JmxHost}}.
+LOCAL_JMX=no
+{{end}}
+if [ "$LOCAL_JMX" = "yes" ]; then
+    JVM_OPTS="$JVM_OPTS -Dcassandra.jmx.local.port=$JMX_PORT"
+    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.authenticate=false"
+else
+    JVM_OPTS="$JVM_OPTS -Dcassandra.jmx.remote.port=$JMX_PORT"
+    # The RMI registry and the JMX server use the same port so only one port has to be opened.
+    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.rmi.port=$JMX_PORT"
+    JVM_OPTS="$JVM_OPTS -Djava.rmi.server.hostname={{.JmxHost}}"
+    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.ssl=false"
+{{if .JmxAuthenticate}}    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.authenticate=true"
+    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.password.file={{.JmxPasswordFile}}"
+{{else}}    JVM_OPTS="$JVM_OPTS -Dcom.sun.management.jmxremote.authenticate=false"
+{{end}}fi
+
+##################
+# EXTRA JVM OPTS #
+##################
+{{range .ExtraJvmOpts}}
+JVM_OPTS="$JVM_OPTS {{shellEscape .}}"{{end}}
+
+JVM_OPTS="$JVM_OPTS $JVM_EXTRA_OPTS"
+`
@@ -0,0 +1,29 @@
+package impl
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestExtraJvmOptsAreEscaped(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run the generated lines")
+	}
+	opts := []string{`-Dname="quoted"`, "-Dhome=$HOME", "-Dcmd=`id`", "-Dsub=$(id)", `-Dpath=C:\dir`}
+	rendered := renderTemplate(t, `{{range .ExtraJvmOpts}}
+JVM_OPTS="$JVM_OPTS {{shellEscape .}}"{{end}}`, &Config{ExtraJvmOpts: opts})
+	if !strings.Contains(CassandraEnvTemplate, `JVM_OPTS="$JVM_OPTS {{shellEscape .}}"`) {
+		t.Fatal("expected cassandra-env.sh to escape the extra JVM options")
+	}
+
+	output, err := exec.Command(sh, "-c", `JVM_OPTS=""`+rendered+`
+printf '%s' "$JVM_OPTS"`).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, output)
+	}
+	if expected := " " + strings.Join(opts, " "); string(output) != expected {
+		t.Errorf("expected %q, got %q", expected, output)
+	}
+}
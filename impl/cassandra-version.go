@@ -0,0 +1,79 @@
+package impl
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TemplateSet is the embedded templates that match a Cassandra release.
+type TemplateSet struct {
+	//Oldest Cassandra version the set applies to, i.e., 4.1
+	Version string
+	Yaml    string
+	//single for jvm.options, split for jvm-server.options and the per JDK files.
+	JvmOptionsLayout string
+}
+
+// TemplateSets are ordered from newest to oldest.
+var TemplateSets = []TemplateSet{
+	{Version: "5.0", Yaml: YamlTemplate50, JvmOptionsLayout: "split"},
+	{Version: "4.1", Yaml: YamlTemplate41, JvmOptionsLayout: "split"},
+	{Version: "4.0", Yaml: YamlTemplate40, JvmOptionsLayout: "split"},
+	{Version: "3.11", Yaml: YamlTemplate, JvmOptionsLayout: "single"},
+}
+
+var cassandraJarPattern = regexp.MustCompile(`^apache-cassandra-(\d+\.\d+(\.\d+)?)([-.][A-Za-z0-9.-]+)?\.jar$`)
+
+// LookupTemplateSet returns the newest template set whose version is not newer than cassandraVersion.
+// Versions older than 3.11 get the 3.11 set.
+func LookupTemplateSet(cassandraVersion string) TemplateSet {
+	for _, set := range TemplateSets {
+		if CompareVersions(cassandraVersion, set.Version) >= 0 {
+			return set
+		}
+	}
+	return TemplateSets[len(TemplateSets)-1]
+}
+
+// DetectCassandraVersion reads the version from the name of $CassandraHome/lib/apache-cassandra-*.jar.
+func DetectCassandraVersion(cassandraHome string) (string, error) {
+	jars, err := filepath.Glob(filepath.Join(cassandraHome, "lib", "apache-cassandra-*.jar"))
+	if err != nil {
+		return "", err
+	}
+	for _, jar := range jars {
+		// Skips apache-cassandra-thrift-*.jar and apache-cassandra-clientutil-*.jar.
+		if match := cassandraJarPattern.FindStringSubmatch(filepath.Base(jar)); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", fmt.Errorf("No apache-cassandra-*.jar found in %s/lib", cassandraHome)
+}
+
+// CompareVersions compares dotted versions such as 3.11.4 and 4.1 numerically and returns -1, 0 or 1.
+// Missing parts count as 0 so 4.1 equals 4.1.0.
+func CompareVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		aPart, bPart := versionPart(aParts, i), versionPart(bParts, i)
+		if aPart < bPart {
+			return -1
+		}
+		if aPart > bPart {
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionPart(parts []string, index int) int {
+	if index >= len(parts) {
+		return 0
+	}
+	number, _ := strconv.Atoi(parts[index])
+	return number
+}
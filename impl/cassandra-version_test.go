@@ -0,0 +1,100 @@
+package impl
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"4.1", "4.1.0", 0},
+		{"3.11.4", "4.0", -1},
+		{"4.0.11", "4.0.2", 1},
+		{"5.0", "4.1", 1},
+		{"3.11", "3.9", 1},
+		{"", "3.11", -1},
+	}
+	for _, test := range tests {
+		if compared := CompareVersions(test.a, test.b); compared != test.expected {
+			t.Errorf("%s and %s: expected %d, got %d", test.a, test.b, test.expected, compared)
+		}
+	}
+}
+
+func TestLookupTemplateSetFromJar(t *testing.T) {
+	tests := []struct {
+		jars     []string
+		version  string
+		expected string
+	}{
+		{[]string{"apache-cassandra-3.11.4.jar", "apache-cassandra-thrift-3.11.4.jar"}, "3.11.4", "3.11"},
+		{[]string{"apache-cassandra-clientutil-4.0.11.jar", "apache-cassandra-4.0.11.jar"}, "4.0.11", "4.0"},
+		{[]string{"apache-cassandra-4.1-beta1.jar"}, "4.1", "4.1"},
+		{[]string{"apache-cassandra-5.0.2.jar"}, "5.0.2", "5.0"},
+		{[]string{"apache-cassandra-3.0.29.jar"}, "3.0.29", "3.11"},
+	}
+	for _, test := range tests {
+		files := make(map[string]string)
+		for _, jar := range test.jars {
+			files["lib/"+jar] = ""
+		}
+		version, err := DetectCassandraVersion(writeFixture(t, files))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != test.version {
+			t.Errorf("%v: expected version %s, got %s", test.jars, test.version, version)
+		}
+		if set := LookupTemplateSet(version); set.Version != test.expected {
+			t.Errorf("%s: expected the %s template set, got %s", version, test.expected, set.Version)
+		}
+	}
+	if _, err := DetectCassandraVersion(t.TempDir()); err == nil {
+		t.Error("expected an error without an apache-cassandra jar")
+	}
+}
+
+// The 4.x templates are built from the 3.11 one, a change that stops a rename from matching leaves the old key.
+func TestYamlTemplateSets(t *testing.T) {
+	oldKey := regexp.MustCompile(`(?m)^(# )?[a-z_]+(_in_ms|_in_kb|_in_mb|_in_minutes|_mb|_mb_per_sec|_megabits_per_sec):`)
+	tests := []struct {
+		version  string
+		present  []string
+		absent   []string
+		oldNames bool
+	}{
+		{"3.11", []string{"start_rpc: false", "read_request_timeout_in_ms: 5000"}, []string{"storage_compatibility_mode"}, true},
+		{"4.0", []string{"read_request_timeout_in_ms: 5000", "enable_user_defined_functions: false"},
+			[]string{"start_rpc", "rpc_port", "thrift_", "request_scheduler", "streaming_socket_timeout_in_ms"}, true},
+		{"4.1", []string{"read_request_timeout: 5000ms", "internode_timeout: true", "scripted_user_defined_functions_enabled"},
+			[]string{"windows_timer_interval", "storage_compatibility_mode", "start_rpc"}, false},
+		{"5.0", []string{"read_request_timeout: 5000ms", "storage_compatibility_mode: CASSANDRA_4"},
+			[]string{"scripted_user_defined_functions_enabled", "compaction_large_partition_warning_threshold"}, false},
+	}
+	for _, test := range tests {
+		set := LookupTemplateSet(test.version)
+		if _, err := template.New(test.version).Funcs(TemplateFuncs(&Config{})).Parse(set.Yaml); err != nil {
+			t.Errorf("%s: the cassandra.yaml template does not parse: %s", test.version, err)
+		}
+		for _, text := range test.present {
+			if !strings.Contains(set.Yaml, text) {
+				t.Errorf("%s: expected %q in the cassandra.yaml template", test.version, text)
+			}
+		}
+		for _, text := range test.absent {
+			if strings.Contains(set.Yaml, text) {
+				t.Errorf("%s: expected no %q in the cassandra.yaml template", test.version, text)
+			}
+		}
+		if !test.oldNames {
+			if found := oldKey.FindString(set.Yaml); found != "" {
+				t.Errorf("%s: expected the 4.1 names, found %q", test.version, found)
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"gopkg.in/yaml.v3"
+)
+
+// Default locations of the OpenStack config drive, mounted from the config-2 volume, and of the NoCloud seed directory.
+const (
+	ConfigDriveDirectory = "/mnt/config"
+	NoCloudDirectory     = "/var/lib/cloud/seed/nocloud"
+)
+
+// UserDataCloudConfigHeader is the first line of a user_data that carries a cloud.conf fragment.
+const UserDataCloudConfigHeader = "#cassandra-cloud"
+
+// DiscoverConfigDrive reads meta_data.json, user_data and network_data.json from openstack/latest of the config drive.
+func DiscoverConfigDrive(directory string, logger lg.Logger) (*InstanceMetadata, error) {
+	if directory == "" {
+		directory = ConfigDriveDirectory
+	}
+	return readConfigDrive(directory, logger)
+}
+
+// DiscoverNoCloud reads a NoCloud seed directory, which has the same files as a config drive
+// or the meta-data and user-data files of cloud-init.
+func DiscoverNoCloud(directory string, logger lg.Logger) (*InstanceMetadata, error) {
+	if directory == "" {
+		directory = NoCloudDirectory
+	}
+	return readConfigDrive(directory, logger)
+}
+
+func readConfigDrive(directory string, logger lg.Logger) (*InstanceMetadata, error) {
+	if latest := filepath.Join(directory, "openstack", "latest"); isDirectory(latest) {
+		directory = latest
+	}
+	logger.Debug("Reading the config drive", directory)
+
+	contents, err := readFirstFile(directory, "meta_data.json", "meta-data")
+	if err != nil {
+		return nil, err
+	}
+	if contents == nil {
+		return nil, fmt.Errorf("%s has no meta_data.json or meta-data", directory)
+	}
+	metaData := make(map[string]interface{})
+	if err := yaml.Unmarshal(contents, &metaData); err != nil {
+		// meta-data is YAML, but a JSON meta_data.json can be indented with tabs which YAML does not allow.
+		if jsonErr := json.Unmarshal(contents, &metaData); jsonErr != nil {
+			return nil, fmt.Errorf("Unable to read the meta data in %s: %s", directory, err)
+		}
+	}
+
+	metadata := &InstanceMetadata{
+		InstanceID: metaDataString(metaData, "uuid", "instance-id"),
+		Hostname:   metaDataString(metaData, "hostname", "local-hostname"),
+		Zone:       metaDataString(metaData, "availability_zone", "availability-zone"),
+		Project:    metaDataString(metaData, "project_id"),
+		Attributes: make(map[string]string),
+	}
+	if meta, ok := metaData["meta"].(map[string]interface{}); ok {
+		for key, value := range meta {
+			metadata.Attributes[key] = fmt.Sprint(value)
+		}
+	}
+	metadata.Rack = metadata.Zone
+
+	networkData, err := readFirstFile(directory, "network_data.json")
+	if err != nil {
+		return nil, err
+	}
+	if networkData != nil {
+		if metadata.PrivateAddress, err = networkDataAddress(networkData); err != nil {
+			return nil, fmt.Errorf("Unable to read network_data.json in %s: %s", directory, err)
+		}
+	}
+
+	userData, err := readFirstFile(directory, "user_data", "user-data")
+	if err != nil {
+		return nil, err
+	}
+	if isCloudConfigFragment(string(userData)) {
+		metadata.CloudConfig = string(userData)
+	} else if userData != nil {
+		logger.Debug("user_data does not start with", UserDataCloudConfigHeader, "so it is not merged")
+	}
+	return metadata, nil
+}
+
+// networkDataAddress returns the first static IPv4 address of network_data.json, or the first IPv6 address.
+func networkDataAddress(networkData []byte) (string, error) {
+	var network struct {
+		Networks []struct {
+			Type      string `json:"type"`
+			IPAddress string `json:"ip_address"`
+		} `json:"networks"`
+	}
+	if err := json.Unmarshal(networkData, &network); err != nil {
+		return "", err
+	}
+	var ipv6 string
+	for _, network := range network.Networks {
+		if network.IPAddress == "" {
+			continue
+		}
+		if network.Type == "ipv4" {
+			return network.IPAddress, nil
+		}
+		if ipv6 == "" && network.Type == "ipv6" {
+			ipv6 = network.IPAddress
+		}
+	}
+	return ipv6, nil
+}
+
+func isCloudConfigFragment(userData string) bool {
+	return strings.HasPrefix(strings.TrimSpace(userData), UserDataCloudConfigHeader)
+}
+
+// mergeCloudConfig merges a cloud.conf fragment into the config before the defaults are set. The keys of the
+// fragment replace the values from cloud.conf, maps like yaml_overrides are merged by key. The environment and
+// the command line are applied afterwards so they still win.
+func mergeCloudConfig(config *Config, fragment string, logger lg.Logger) error {
+	file, err := hcl.Parse(fragment)
+	if err != nil {
+		return err
+	}
+	keys := make(map[string]bool)
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		for _, item := range list.Items {
+			if key, ok := item.Keys[0].Token.Value().(string); ok {
+				keys[key] = true
+			}
+		}
+	}
+	fragmentConfig := &Config{}
+	if err := hcl.DecodeObject(fragmentConfig, file); err != nil {
+		return err
+	}
+	initYamlOverrides(fragmentConfig, logger)
+
+	target := reflect.ValueOf(config).Elem()
+	source := reflect.ValueOf(fragmentConfig).Elem()
+	for index := 0; index < target.NumField(); index++ {
+		name := strings.Split(target.Type().Field(index).Tag.Get("hcl"), ",")[0]
+		if !keys[name] {
+			continue
+		}
+		logger.Debug("Using", name, "from user_data")
+		targetField, sourceField := target.Field(index), source.Field(index)
+		if targetField.Kind() == reflect.Map && !targetField.IsNil() {
+			for _, key := range sourceField.MapKeys() {
+				targetField.SetMapIndex(key, sourceField.MapIndex(key))
+			}
+			continue
+		}
+		targetField.Set(sourceField)
+	}
+	return nil
+}
+
+// readFirstFile reads the first of the files that exists in the directory, nil if none of them exist.
+func readFirstFile(directory string, names ...string) ([]byte, error) {
+	for _, name := range names {
+		contents, err := ioutil.ReadFile(filepath.Join(directory, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		return contents, err
+	}
+	return nil, nil
+}
+
+func metaDataString(metaData map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := metaData[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
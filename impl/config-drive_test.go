@@ -0,0 +1,138 @@
+package impl
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/hcl"
+)
+
+const testUserData = `#cassandra-cloud
+cluster_name = "from-user-data"
+num_tokens = 16
+yaml_overrides {
+  concurrent_reads = 64
+}
+`
+
+func TestReadConfigDrive(t *testing.T) {
+	directory := writeFixture(t, map[string]string{
+		"openstack/latest/meta_data.json": `{
+	"uuid": "83679162-1378-4288-a2d4-70e13ec132aa",
+	"hostname": "cassandra-0.novalocal",
+	"availability_zone": "nova",
+	"project_id": "f7ac731cc11f40efbc03a9f9e1d1d21f",
+	"meta": {"cassandra-seed": "true", "replicas": 3}
+}`,
+		"openstack/latest/network_data.json": `{"networks": [
+	{"id": "network0", "type": "ipv6", "ip_address": "fd00::5"},
+	{"id": "network1", "type": "ipv4", "ip_address": "10.0.0.5"}
+]}`,
+		"openstack/latest/user_data": testUserData,
+	})
+	metadata, err := DiscoverConfigDrive(directory, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := InstanceMetadata{
+		InstanceID:     "83679162-1378-4288-a2d4-70e13ec132aa",
+		Hostname:       "cassandra-0.novalocal",
+		PrivateAddress: "10.0.0.5",
+		Zone:           "nova",
+		Project:        "f7ac731cc11f40efbc03a9f9e1d1d21f",
+		Attributes:     map[string]string{"cassandra-seed": "true", "replicas": "3"},
+		Rack:           "nova",
+		CloudConfig:    testUserData,
+	}
+	if !reflect.DeepEqual(*metadata, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *metadata)
+	}
+}
+
+func TestReadNoCloud(t *testing.T) {
+	directory := writeFixture(t, map[string]string{
+		"meta-data": "instance-id: iid-cassandra-0\nlocal-hostname: cassandra-0\n",
+		"user-data": "#cloud-config\npackages: [cassandra]\n",
+	})
+	metadata, err := DiscoverNoCloud(directory, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.InstanceID != "iid-cassandra-0" || metadata.Hostname != "cassandra-0" {
+		t.Errorf("expected the NoCloud instance id and hostname, got %+v", *metadata)
+	}
+	if metadata.PrivateAddress != "" || metadata.CloudConfig != "" {
+		t.Errorf("expected no address and a cloud-init user-data that is not merged, got %+v", *metadata)
+	}
+}
+
+func TestReadConfigDriveFailures(t *testing.T) {
+	tests := map[string]map[string]string{
+		"no meta data":     {"user-data": testUserData},
+		"bad meta data":    {"meta-data": "{ [ not yaml or json"},
+		"bad network data": {"meta_data.json": `{"uuid": "1"}`, "network_data.json": "{"},
+	}
+	for name, files := range tests {
+		if _, err := readConfigDrive(writeFixture(t, files), testLogger()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestNetworkDataAddress(t *testing.T) {
+	tests := []struct {
+		networkData string
+		expected    string
+	}{
+		{`{"networks": [{"type": "ipv4", "ip_address": "10.0.0.5"}, {"type": "ipv4", "ip_address": "10.0.0.6"}]}`, "10.0.0.5"},
+		{`{"networks": [{"type": "ipv6", "ip_address": "fd00::5"}, {"type": "ipv4", "ip_address": "10.0.0.5"}]}`, "10.0.0.5"},
+		{`{"networks": [{"type": "ipv4_dhcp"}, {"type": "ipv6", "ip_address": "fd00::5"}]}`, "fd00::5"},
+		{`{"networks": []}`, ""},
+	}
+	for _, test := range tests {
+		address, err := networkDataAddress([]byte(test.networkData))
+		if err != nil || address != test.expected {
+			t.Errorf("%s: expected %s, got %s and %v", test.networkData, test.expected, address, err)
+		}
+	}
+}
+
+func TestMergeCloudConfig(t *testing.T) {
+	config := &Config{}
+	if err := hcl.Decode(config, `
+cluster_name = "from-cloud-conf"
+datacenter = "dc1"
+num_tokens = 256
+yaml_overrides {
+  concurrent_reads = 32
+  concurrent_writes = 32
+}
+`); err != nil {
+		t.Fatal(err)
+	}
+	initYamlOverrides(config, testLogger())
+
+	if err := mergeCloudConfig(config, testUserData, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if config.ClusterName != "from-user-data" || config.NumTokens != 16 {
+		t.Errorf("expected the user data to replace cloud.conf, got %s and %d", config.ClusterName, config.NumTokens)
+	}
+	if config.Datacenter != "dc1" {
+		t.Errorf("expected the keys missing from the user data to be kept, got %s", config.Datacenter)
+	}
+	expected := YamlOverrides{"concurrent_reads": 64, "concurrent_writes": 32}
+	if !reflect.DeepEqual(config.YamlOverrides, expected) {
+		t.Errorf("expected the yaml overrides to be merged by key, got %v", config.YamlOverrides)
+	}
+
+	t.Setenv("CASSANDRA_CLUSTER_NAME", "from-env")
+	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_NAME", &config.ClusterName, "", testLogger())
+	if config.ClusterName != "from-env" {
+		t.Errorf("expected the environment to win over the user data, got %s", config.ClusterName)
+	}
+
+	if err := mergeCloudConfig(config, `cluster_name = "unterminated`, testLogger()); err == nil {
+		t.Error("expected an error for a fragment that does not parse")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"io/ioutil"
 	"github.com/hashicorp/hcl"
 	lg "github.com/advantageous/go-logback/logging"
-	"runtime"
 	"os"
 	"strconv"
 	"flag"
@@ -12,17 +11,44 @@ import (
 	"reflect"
 	"strings"
 	"C"
-	"os/exec"
 )
 
 type Config struct {
 	DataDirs []string `hcl:"data_dirs"`
 
 	CassandraHome string `hcl:"home_dir"`
+	//Cassandra version, i.e., 3.11.4 or 4.1.3. Defaults to the version of $CassandraHome/lib/apache-cassandra-*.jar.
+	//Picks the embedded templates for 3.11, 4.0, 4.1 or 5.0.
+	CassandraVersion string `hcl:"cassandra_version"`
 	// Addresses of hosts that are deemed contact points.
 	// Cassandra nodes use this list of hosts to find each other and learn
 	// the topology of the ring.  You must change this if you are running  multiple nodes!
+	// Entries can also be dns:<name> for the A and AAAA records of a name, or srv:<name> for the targets of SRV records.
 	ClusterSeeds string `hcl:"cluster_seeds"`
+	//cluster_seeds with the dns:, srv:, ec2: and consul: entries resolved when the config is loaded, i.e., {{.Seeds}}.
+	Seeds string `hcl:"-"`
+	//Maximum number of seeds written to cassandra.yaml after sorting and removing duplicates. 0 writes all of them.
+	SeedsMaxCount int `hcl:"cluster_seeds_max_count"`
+	//DNS server, host:port, used for the dns: and srv: seeds. Defaults to the resolver of the system.
+	SeedsResolver string `hcl:"cluster_seeds_resolver"`
+	//Tags of the instances used by ec2: seeds. Defaults to cassandra-cluster=<cluster_name> and cassandra-seed=true.
+	Ec2SeedTags Ec2Tags `hcl:"ec2_seed_tags"`
+	//Region for ec2: seeds. Defaults to the region from EC2 discovery, then AWS_REGION.
+	Ec2Region string `hcl:"ec2_region"`
+	//EC2 API endpoint, i.e., a local mock. Defaults to https://ec2.<region>.amazonaws.com.
+	Ec2Endpoint string `hcl:"ec2_endpoint"`
+	//HTTP API of the Consul agent used by consul: seeds and consul_register.
+	ConsulAddress string `hcl:"consul_address"`
+	//Consul ACL token. Defaults to CONSUL_HTTP_TOKEN.
+	ConsulToken string `hcl:"consul_token"`
+	//Consul service of the Cassandra nodes. Used by consul_register and consul: seeds without a service.
+	ConsulService string `hcl:"consul_service"`
+	//Only use the instances with this tag as consul: seeds, i.e., seed.
+	ConsulSeedTag string `hcl:"consul_seed_tag"`
+	//Register this node with the Consul agent, with a TCP check on cluster_port.
+	ConsulRegister bool `hcl:"consul_register"`
+	//Tags of the Consul service instance of this node.
+	ConsulTags []string `hcl:"consul_tags"`
 	// Address or interface to bind to and tell other Cassandra nodes to connect to.
 	// You _must_ change this if you want multiple nodes to be able to communicate!
 	// Set listen_address OR listen_interface, not both.
@@ -39,45 +65,172 @@ type Config struct {
 	ClientListenAddress string `hcl:"client_address"`
 	// Interface to listen for client connections. Address and interface can't both be set.
 	ClientListenInterface string `hcl:"client_interface"`
+	//IP version used for cluster_interface and client_interface when they have both. Values: ipv4, ipv6.
+	InterfaceIPPreference string `hcl:"interface_ip_preference"`
+	//Write the address of cluster_interface and client_interface as listen_address and rpc_address instead of the interface.
+	ResolveInterfaces bool `hcl:"resolve_interfaces"`
+	//Address clients are told to connect to, broadcast_rpc_address. Required when client_address is 0.0.0.0.
+	ClientBroadcastAddress string `hcl:"client_broadcast_address"`
 	ClientPort int `hcl:"client_port"`
 	//Name of the cassandra cluster.
 	ClusterName string `hcl:"cluster_name"`
 	ClusterPort    int `hcl:"cluster_port"`
 	ClusterSslPort int `hcl:"cluster_ssl_port"`
-	//AUTO, or a number string, i.e., 100MB
-	CmsYoungGenSize string `hcl:"cms_young_gen_size"`
+	//AUTO, or a size, i.e., 100MB
+	CmsYoungGenSize Size `hcl:"cms_young_gen_size"`
 	CommitLogDir string `hcl:"commit_log_dir"`
+	//Number of CPUs used for ergonomics. 0 detects the CPUs allowed by the cgroup quota and cpuset.
+	CpuCount int `hcl:"cpu_count"`
+	//Number of disks used for ergonomics. 0 counts the devices that hold the data directories.
+	DataDiskCount int `hcl:"data_disk_count"`
+
+	//AUTO (16 * data disks), or a number
+	ConcurrentReads string `hcl:"concurrent_reads"`
+	//AUTO (8 * CPUs), or a number
+	ConcurrentWrites string `hcl:"concurrent_writes"`
+	//AUTO (16 * data disks), or a number
+	ConcurrentCounterWrites string `hcl:"concurrent_counter_writes"`
+	//AUTO (8 * CPUs), or a number
+	ConcurrentMaterializedViewWrites string `hcl:"concurrent_materialized_view_writes"`
 
 	ReplaceAddress string `hcl:"replace_address"`
 
 	//GC stats
 	GCStatsEnabled bool `hcl:"gc_stats_enabled"`
-	// CMS, G1, AUTO - Auto uses G1 if heap is over 8GB (default) but CMS if under.
+	// CMS, G1, ZGC, GENERATIONAL_ZGC, SHENANDOAH, AUTO - Auto uses G1 if memory is over 5GB (default) but CMS if under,
+	// and the low pause collector for big heaps on JDK 15 and later.
 	GC string `hcl:"gc"`
 	//Threshold in GB of when to use G1 vs CMS
 	G1ThresholdGBs int `hcl:"gc_g1_threshold_gbs"`
+	//Low pause collector used by AUTO. Values: ZGC (generational on JDK 21+) or SHENANDOAH.
+	LowPauseGC string `hcl:"gc_low_pause"`
+	//Heap size threshold in GB of when AUTO uses the low pause collector.
+	LowPauseThresholdGBs int `hcl:"gc_low_pause_threshold_gbs"`
+	//AUTO or the number of ZGC concurrent threads
+	ZGCConcGCThreads string `hcl:"zgc_concurrent_threads"`
+	//AUTO (not set), or a size the ZGC tries to keep the heap under, i.e., 20GB. Only set on JDK 13 and later.
+	ZGCSoftMaxHeapSize Size `hcl:"zgc_soft_max_heap_size"`
+	//Shenandoah heuristics. Values: adaptive, static, compact, aggressive.
+	ShenandoahHeuristics string `hcl:"shenandoah_heuristics"`
+	//Major version of the JDK, i.e., 8, 11 or 17. 0 reads it from the release file of the JDK.
+	JavaMajorVersion int `hcl:"java_major_version"`
+	//JDK home. Defaults to JAVA_HOME, then the jdk, jre or java directory under home_dir.
+	JavaHome string `hcl:"java_home"`
 	//AUTO, or a number
 	G1ParallelGCThreads string `hcl:"g1_parallel_threads"`
 	//AUTO or the number or threads
 	G1ConcGCThreads string `hcl:"g1_concurrent_threads"`
 
 
+	//Location of cassandra-env.sh.
+	CassandraEnvFileName string `hcl:"conf_cassandra_env_file"`
+	//Location of the cassandra-env.sh template.
+	CassandraEnvTemplate string `hcl:"conf_cassandra_env_template"`
+	//JMX port. Defaults to 7199.
+	JmxPort int `hcl:"jmx_port"`
+	//localhost (default) only allows local JMX, any other host name or address enables remote JMX
+	//and is the address JMX clients are told to connect to.
+	JmxHost string `hcl:"jmx_host"`
+	//Remote JMX checks the jmx_password_file when true, the default.
+	JmxAuthenticate bool `hcl:"jmx_authenticate"`
+	//Password file of remote JMX. Defaults to /etc/cassandra/jmxremote.password.
+	JmxPasswordFile string `hcl:"jmx_password_file"`
+	//Directory for the Cassandra and GC logs (CASSANDRA_LOG_DIR).
+	LogDir string `hcl:"log_dir"`
+	//Extra options added to JVM_OPTS in cassandra-env.sh. They are escaped, so quotes and $ reach the JVM as written.
+	ExtraJvmOpts []string `hcl:"extra_jvm_opts"`
+
+	//Location of logback.xml.
+	LogbackFileName string `hcl:"conf_logback_file"`
+	//Location of the logback.xml template.
+	LogbackTemplate string `hcl:"conf_logback_template"`
+	//Root log level. Values: TRACE, DEBUG, INFO (default), WARN, ERROR, OFF.
+	LogLevel string `hcl:"log_level"`
+	//Levels of loggers, i.e., log_levels { "org.apache.cassandra.db" = "DEBUG" }. org.apache.cassandra defaults to DEBUG.
+	LogLevels LogLevels `hcl:"log_levels"`
+	//Size a log file rolls over at, i.e., 50MB. At least 1MB.
+	LogMaxFileSize Size `hcl:"log_max_file_size"`
+	//Days of logs to keep.
+	LogMaxHistory int `hcl:"log_max_history"`
+	//Total size of the logs to keep, i.e., 5GB. At least 1MB.
+	LogTotalSizeCap Size `hcl:"log_total_size_cap"`
+	//pattern (default) or json for log shippers.
+	LogFormat string `hcl:"log_format"`
+	//Encoder class used by the json format. Its jar has to be in the Cassandra lib directory.
+	LogJsonEncoder string `hcl:"log_json_encoder"`
+
 	//Location of cassandra jvm options file.
 	JvmOptionsFileName string `hcl:"conf_jvm_options_file"`
 	//Location of jvm options template.
 	JvmOptionsTemplate string `hcl:"conf_jvm_options_template"`
-
-	//AUTO, or a number string, i.e., 5GB
-	MinHeapSize string `hcl:"min_heap_size"`
-	//AUTO, or a number string, i.e., 5GB
-	MaxHeapSize string `hcl:"max_heap_size"`
+	//single writes jvm.options (Cassandra 3.x), split writes jvm-server.options, jvm8-server.options
+	//and jvm11-server.options (Cassandra 4.x, plus jvm17-server.options for 5.0). AUTO picks by Cassandra version.
+	JvmOptionsLayout string `hcl:"conf_jvm_options_layout"`
+	JvmServerOptionsFileName string `hcl:"conf_jvm_server_options_file"`
+	JvmServerOptionsTemplate string `hcl:"conf_jvm_server_options_template"`
+	Jvm8ServerOptionsFileName string `hcl:"conf_jvm8_server_options_file"`
+	Jvm8ServerOptionsTemplate string `hcl:"conf_jvm8_server_options_template"`
+	Jvm11ServerOptionsFileName string `hcl:"conf_jvm11_server_options_file"`
+	Jvm11ServerOptionsTemplate string `hcl:"conf_jvm11_server_options_template"`
+	//Cassandra 5.0 jvm17-server.options, which always has the JDK 17 options.
+	Jvm17ServerOptionsFileName string `hcl:"conf_jvm17_server_options_file"`
+	Jvm17ServerOptionsTemplate string `hcl:"conf_jvm17_server_options_template"`
+
+	//AUTO, or a size, i.e., 5GB
+	MinHeapSize Size `hcl:"min_heap_size"`
+	//AUTO, or a size, i.e., 5GB
+	MaxHeapSize Size `hcl:"max_heap_size"`
+	//Sizes the heap when max_heap_size is AUTO. Values: cassandra-default, percent:N, fixed, g1-large.
+	HeapPolicy string `hcl:"heap_policy"`
 	MultiDataCenter bool `hcl:"multi_dc"`
 
+	//Memory used for heap ergonomics. Values: total, available, cgroup-limit (default).
+	MemoryBasis string `hcl:"memory_basis"`
+	//Root of the file system used to read /proc and /sys/fs/cgroup. Defaults to /.
+	SystemRoot string `hcl:"system_root"`
+
 	//Number of tokens that this node wants/has. Used for Cassandra VNODES.
 	NumTokens int `hcl:"num_tokens"`
 
 	// Cassandra snitch type.
 	Snitch string `hcl:"snitch"`
+	//Data center of this node, written to cassandra-rackdc.properties for GossipingPropertyFileSnitch.
+	Datacenter string `hcl:"datacenter"`
+	//Rack of this node, written to cassandra-rackdc.properties for GossipingPropertyFileSnitch.
+	Rack string `hcl:"rack"`
+	//Suffix added to the data center name.
+	DcSuffix string `hcl:"dc_suffix"`
+	//Prefer the internal IP to talk to nodes in the same data center.
+	PreferLocal bool `hcl:"prefer_local"`
+	//Location of cassandra-rackdc.properties.
+	RackDCFileName string `hcl:"conf_rackdc_file"`
+	//Location of the cassandra-rackdc.properties template.
+	RackDCTemplate string `hcl:"conf_rackdc_template"`
+	//Nodes and their data centers and racks, written to cassandra-topology.properties for PropertyFileSnitch.
+	Topology Topology `hcl:"topology"`
+	//Location of cassandra-topology.properties.
+	TopologyFileName string `hcl:"conf_topology_file"`
+	//Location of the cassandra-topology.properties template.
+	TopologyTemplate string `hcl:"conf_topology_template"`
+
+	//Cloud metadata service used to fill in the addresses, data center and rack that are not set. Values: none, ec2, gce, azure, configdrive, nocloud.
+	Discovery string `hcl:"discovery"`
+	//Base URL of the metadata service, i.e., http://127.0.0.1:8080 for a local stand-in, or the directory for configdrive and nocloud. Empty uses the provider default.
+	DiscoveryEndpoint string `hcl:"discovery_endpoint"`
+	//What discovery learned about the instance, i.e., {{.Instance.InstanceType}}.
+	Instance InstanceMetadata `hcl:"-"`
+	//Kubernetes StatefulSet profile, sets the addresses and seeds from the pod. Set in cloud.conf or with CASSANDRA_KUBERNETES.
+	Kubernetes bool `hcl:"kubernetes"`
+	//Headless service of the StatefulSet used for the seeds. Defaults to the name of the StatefulSet.
+	KubernetesService string `hcl:"kubernetes_service"`
+	//Number of pods of the StatefulSet, starting at ordinal 0, used as seeds.
+	KubernetesSeedCount int `hcl:"kubernetes_seed_count"`
+	//Cluster domain, i.e., cluster.local. When set the seeds are <sts>-0.<svc>.<namespace>.svc.<domain>.
+	KubernetesClusterDomain string `hcl:"kubernetes_cluster_domain"`
+	//Directory of the downward API volume with the name and namespace files.
+	KubernetesPodInfoDir string `hcl:"kubernetes_pod_info_dir"`
+	//Pod read by the Kubernetes profile, i.e., {{.Pod.Ordinal}}.
+	Pod KubernetesPod `hcl:"-"`
 
 	Verbose bool `hcl:"verbose"`
 
@@ -86,6 +239,14 @@ type Config struct {
 	YamlConfigTemplate string `hcl:"conf_yaml_template"`
 	//Location of cassandra yaml config file.
 	YamlConfigFileName string `hcl:"conf_yaml_file"`
+	//template renders conf_yaml_template, patch edits the managed keys of conf_yaml_source_file and keeps the rest.
+	YamlMode string `hcl:"conf_yaml_mode"`
+	//Location of the stock cassandra.yaml that patch mode edits, kept apart from conf_yaml_file so every run starts from it.
+	YamlSourceFileName string `hcl:"conf_yaml_source_file"`
+	//Templates to generate, the built in cassandra.yaml and jvm options templates plus the template blocks in cloud.conf.
+	Templates []TemplateFile `hcl:"template"`
+	//cassandra.yaml keys deep merged into the generated cassandra.yaml, i.e., yaml_overrides { compaction_throughput_mb_per_sec = 64 }
+	YamlOverrides YamlOverrides `hcl:"yaml_overrides"`
 
 
 }
@@ -135,15 +296,42 @@ func displayConfig(config *Config) {
 }
 
 func LoadConfigFromString(data string, logger lg.Logger) (*Config, error) {
-	config := &Config{}
+	// Bools that default to true are set before decoding, cloud.conf only changes the keys it has.
+	config := &Config{JmxAuthenticate: true}
 
 	logger.Debug("Loading log...")
-	err := hcl.Decode(&config, data)
+	err := hcl.Decode(config, data)
 	if err != nil {
 		return nil, err
 	}
-	initDefaults(config, logger)
+	if err := initDiscovery(config, logger); err != nil {
+		return nil, err
+	}
+	if err := initDefaults(config, logger); err != nil {
+		return nil, err
+	}
 	bindCommandlineArgs(config, logger)
+	applyInstanceMetadata(config, logger)
+	if err := initListenAddresses(config, logger); err != nil {
+		return nil, err
+	}
+	if err := initInterfaces(config, logger); err != nil {
+		return nil, err
+	}
+	initVersions(config, logger)
+	if err := initErgonomics(config, logger); err != nil {
+		return nil, err
+	}
+	if err := validateSnitch(config); err != nil {
+		return nil, err
+	}
+	if err := validateYamlSource(config); err != nil {
+		return nil, err
+	}
+	if err := initSeeds(config, logger); err != nil {
+		return nil, err
+	}
+	initTemplates(config, logger)
 
 	if config.Verbose {
 		displayConfig(config)
@@ -163,6 +351,45 @@ cluster_name = "My Cluster"
 # Comma delimited list of seed hosts. Defaults to 127.0.0.1. This is used for bootstrap only.
 # Production clusters should have at least two seed servers.
 # cluster_seeds = 127.0.0.1
+# Entries with a dns: prefix use the A and AAAA records of the name, entries with a srv: prefix use
+# the addresses of the targets of the SRV records. They are looked up once when cloud.conf is loaded and
+# nothing is written when a lookup fails.
+# cluster_seeds = "dns:cassandra-seeds.internal,srv:_cassandra._tcp.prod.internal"
+# The seeds are sorted and duplicates removed, this keeps the first ones. Defaults to 0, all of them.
+# cluster_seeds_max_count = 3
+# DNS server used for dns: and srv: seeds, host:port. Defaults to the resolver of the system.
+# cluster_seeds_resolver = "10.0.0.2:53"
+# ec2:region uses the running instances with the ec2_seed_tags in the region of this node, ec2:az only
+# the ones in its availability zone (needs discovery = "ec2") and ec2:<region>, i.e., ec2:us-west-2, the
+# ones in another region by their public IP. Credentials come from AWS_ACCESS_KEY_ID,
+# AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN or the IAM role of the instance.
+# cluster_seeds = "ec2:region,ec2:us-west-2"
+# Defaults to cassandra-cluster = <cluster_name> and cassandra-seed = true.
+# ec2_seed_tags {
+#   cassandra-cluster = "prod"
+#   cassandra-seed = "true"
+# }
+# Defaults to the region from EC2 discovery, then AWS_REGION.
+# ec2_region = "us-east-1"
+# EC2 API endpoint, i.e., a local mock. Defaults to https://ec2.<region>.amazonaws.com.
+# ec2_endpoint = "http://127.0.0.1:8080"
+# consul:<service> uses the instances of a Consul service that pass their health checks, consul:<service>@<dc>
+# the ones in another Consul data center. consul: uses consul_service. When no instance passes, i.e., on a new
+# cluster, every registered instance is used, plus this node with consul_register.
+# cluster_seeds = "consul:cassandra"
+# Defaults to http://127.0.0.1:8500.
+# consul_address = "http://127.0.0.1:8500"
+# Defaults to CONSUL_HTTP_TOKEN.
+# consul_token = "..."
+# Defaults to cassandra.
+# consul_service = "cassandra"
+# Only use the instances with this tag as seeds.
+# consul_seed_tag = "seed"
+# Registers this node as an instance of consul_service with its data center, rack and ports as meta data
+# and a TCP check on cluster_port, so other nodes prefer it as a seed once Cassandra runs. The node only
+# registers after every file was written.
+# consul_register = true
+# consul_tags = ["seed"]
 
 # Cassandra home directory. Defaults to /opt/cassandra.
 # home_dir = /opt/cassandra
@@ -178,9 +405,83 @@ cluster_name = "My Cluster"
 # client_address=localhost
 # client_interface=eth0
 
+# cassandra-cloud checks that cluster_interface and client_interface exist and uses the address of
+# cluster_interface as cluster_broadcast_address when it is not set. interface_ip_preference picks the
+# IP version when an interface has both, ipv4 (default) or ipv6. resolve_interfaces writes the address
+# as listen_address and rpc_address instead of listen_interface and rpc_interface.
+# interface_ip_preference = "ipv4"
+# resolve_interfaces = true
+
+# Address clients are told to connect to (broadcast_rpc_address). Required when client_address is 0.0.0.0.
+# client_broadcast_address = 10.0.0.5
+
 # Sets the snitch type for Cassandra. Defaults to simple snitch (for now).
 # snitch=SimpleSnitch
 
+# GossipingPropertyFileSnitch reads the data center and rack from cassandra-rackdc.properties
+# which is generated from conf_rackdc_template. datacenter and rack are required for it.
+# datacenter = us-east
+# rack = rack1
+# dc_suffix = _analytics
+# prefer_local = true
+# conf_rackdc_template = /opt/cassandra/conf/cassandra-rackdc.template
+# conf_rackdc_file = /opt/cassandra/conf/cassandra-rackdc.properties
+
+# PropertyFileSnitch reads every node from cassandra-topology.properties which is generated from
+# the topology block and conf_topology_template. The address of this node has to be in the block.
+# The defaults are used for nodes that are not listed and default to datacenter and rack.
+# topology {
+#   default_datacenter = "us-east"
+#   default_rack = "rack1"
+#   node "10.0.0.1" {
+#     datacenter = "us-east"
+#     rack = "rack1"
+#   }
+#   node "10.0.1.1" {
+#     datacenter = "us-west"
+#     rack = "rack1"
+#   }
+# }
+# conf_topology_template = /opt/cassandra/conf/cassandra-topology.template
+# conf_topology_file = /opt/cassandra/conf/cassandra-topology.properties
+
+# Fills in cluster_address, client_address, cluster_broadcast_address, datacenter and rack from the
+# metadata service of the cloud when they are not set. Values: none (default), ec2, gce, azure,
+# configdrive (OpenStack config drive) or nocloud (cloud-init NoCloud seed directory).
+# The broadcast address is the private IP, or the public IP with multi_dc when the instance has one. It is
+# only set when the cluster address was discovered too.
+# ec2 uses IMDSv2, the data center is the region and the rack is the availability zone, i.e., us-east-1
+# and us-east-1a.
+# gce names them like GoogleCloudSnitch, zone us-central1-a is data center us-central1 and rack a.
+# azure names them like AzureSnitch, the data center is the location and the rack is the zone, or the
+# fault domain when the VM is not in a zone, i.e., eastus and rack-1.
+# configdrive and nocloud read meta_data.json (or meta-data), network_data.json and user_data (or user-data)
+# from the directory, or its openstack/latest directory. The rack is the availability zone.
+# A user_data that starts with #cassandra-cloud is a cloud.conf fragment merged into this config before
+# the defaults are set, so it can also set home_dir or kubernetes. Its keys win over this file, the environment
+# and the command line win over it.
+# discovery = "ec2"
+# Base URL of the metadata service, i.e., a local stand-in. Defaults to http://169.254.169.254 for ec2
+# and azure, http://metadata.google.internal for gce. The directory for configdrive, defaults to /mnt/config,
+# and nocloud, defaults to /var/lib/cloud/seed/nocloud.
+# discovery_endpoint = "http://127.0.0.1:8080"
+
+# Kubernetes StatefulSet profile for running cassandra-cloud as an init container. The pod name,
+# namespace and IP are read from the POD_NAME, POD_NAMESPACE and POD_IP downward API environment
+# variables, or the name and namespace files of a downward API volume in kubernetes_pod_info_dir.
+# Settings that are not set in this file or with their environment variable default to:
+# cluster_address and cluster_broadcast_address = pod IP, client_address = 0.0.0.0,
+# client_broadcast_address = pod IP, cluster_seeds = <sts>-0.<svc>,<sts>-1.<svc>.
+# The profile is applied before the command line is read, so enable it here or with CASSANDRA_KUBERNETES.
+# cassandra-cloud stops without writing any file when the pod is not part of a StatefulSet.
+# kubernetes = true
+# Headless service of the StatefulSet. Defaults to the name of the StatefulSet.
+# kubernetes_service = "cassandra"
+# kubernetes_seed_count = 2
+# Makes the seeds fully qualified, <sts>-0.<svc>.<namespace>.svc.cluster.local.
+# kubernetes_cluster_domain = "cluster.local"
+# kubernetes_pod_info_dir = "/etc/podinfo"
+
 # Sets up VNODE weight for servder. Defaults to 32 tokens per node
 # num_tokens=32
 
@@ -201,27 +502,163 @@ cluster_name = "My Cluster"
 
 # Data directories for Cassandra SSTables. Defaults to ["/opt/cassandra/data"]
 # data_dirs = ["/opt/cassandra/data"]
+
+# Memory used for heap ergonomics: total, available or cgroup-limit. Defaults to cgroup-limit
+# which is the container memory limit, or the total memory when there is no limit.
+# memory_basis = cgroup-limit
+
+# Number of CPUs used for GC thread and young gen ergonomics. Defaults to the CPUs
+# allowed by the container CPU quota and cpuset.
+# cpu_count = 4
+
+# Concurrency for cassandra.yaml. AUTO uses 16 * data disks for reads and counter writes,
+# and 8 * CPUs for writes and materialized view writes. Defaults to AUTO.
+# concurrent_reads = AUTO
+# concurrent_writes = AUTO
+# concurrent_counter_writes = AUTO
+# concurrent_materialized_view_writes = AUTO
+# Number of data disks. Defaults to the number of devices that hold data_dirs.
+# data_disk_count = 2
+
+# Heap sizing policy used when max_heap_size is AUTO. Defaults to percent:70.
+# cassandra-default - max(min(1/2 ram, 1GB), min(1/4 ram, 8GB)) like cassandra-env.sh
+# percent:N         - N percent of memory
+# fixed             - uses max_heap_size and cms_young_gen_size as set
+# g1-large          - half of memory for large G1 heaps
+# The policies cap the heap at 31GB so the JVM keeps compressed oops, an explicit max_heap_size is
+# used as set with a warning.
+# heap_policy = cassandra-default
+
+# Garbage collector. Values: CMS, G1, ZGC, GENERATIONAL_ZGC, SHENANDOAH or AUTO. Defaults to AUTO.
+# AUTO uses gc_low_pause for heaps of gc_low_pause_threshold_gbs (24) or more on JDK 15+,
+# G1 if memory is over gc_g1_threshold_gbs (5), and CMS otherwise (G1 on JDK 14+ where CMS is gone).
+# gc = AUTO
+# gc_low_pause = ZGC
+# gc_low_pause_threshold_gbs = 24
+# zgc_concurrent_threads = AUTO
+# zgc_soft_max_heap_size = 20GB
+# shenandoah_heuristics = adaptive
+
+# Major version of the JDK. Defaults to the JAVA_VERSION in the release file of java_home.
+# java_major_version = 11
+# JDK home. Defaults to JAVA_HOME, then the jdk, jre or java directory under home_dir.
+# java_home = /usr/lib/jvm/java-11
+
+# JVM option files. single writes conf_jvm_options_file (jvm.options) for Cassandra 3.x,
+# split writes jvm-server.options, jvm8-server.options and jvm11-server.options for Cassandra 4.x
+# (and jvm17-server.options for 5.0). Defaults to AUTO which picks by cassandra_version.
+# conf_jvm_options_layout = AUTO
+
+# Cassandra version used to pick the embedded templates for 3.11, 4.0, 4.1 or 5.0.
+# Defaults to the version of {{home_dir}}/lib/apache-cassandra-*.jar.
+# cassandra_version = 4.1
+
+# cassandra-env.sh is generated from conf_cassandra_env_template. It sets MAX_HEAP_SIZE and
+# HEAP_NEWSIZE from the heap ergonomics, CASSANDRA_LOG_DIR from log_dir and JMX, and reads
+# jvm.options for the single layout like the stock file. GC logs are written to log_dir.
+# conf_cassandra_env_template = /opt/cassandra/conf/cassandra-env.template
+# conf_cassandra_env_file = /opt/cassandra/conf/cassandra-env.sh
+# JMX port. Defaults to 7199.
+# jmx_port = 7199
+# localhost (default) only allows local JMX. Any other host enables remote JMX, clients are told to connect to it.
+# jmx_host = 10.0.0.10
+# Remote JMX checks jmx_password_file. Defaults to true, only turn it off on a trusted network.
+# jmx_authenticate = false
+# Defaults to /etc/cassandra/jmxremote.password.
+# jmx_password_file = /etc/cassandra/jmxremote.password
+# Log directory. Defaults to {{home_dir}}/logs.
+# log_dir = /var/log/cassandra
+# Extra options added to JVM_OPTS. CASSANDRA_EXTRA_JVM_OPTS and -extra-jvm-opts separate them with spaces.
+# extra_jvm_opts = ["-Dcassandra.ring_delay_ms=30000"]
+
+# logback.xml is generated from conf_logback_template.
+# conf_logback_template = /opt/cassandra/conf/logback.template
+# conf_logback_file = /opt/cassandra/conf/logback.xml
+# Root log level. Defaults to INFO.
+# log_level = INFO
+# Logger levels. CASSANDRA_LOG_LEVELS and -log-levels use org.apache.cassandra.db=DEBUG,com.datastax=WARN.
+# org.apache.cassandra defaults to DEBUG which writes debug.log like the stock logback.xml.
+# log_levels {
+#   "org.apache.cassandra" = "INFO"
+#   "org.apache.cassandra.gms" = "WARN"
+# }
+# Log rotation. Defaults to 50MB files, 7 days of history and 5GB in total.
+# log_max_file_size = 50MB
+# log_max_history = 7
+# log_total_size_cap = 5GB
+# pattern (default) or json. json uses log_json_encoder whose jar has to be in {{home_dir}}/lib.
+# log_format = json
+# log_json_encoder = net.logstash.logback.encoder.LogstashEncoder
+
+# How cassandra.yaml is written. template renders conf_yaml_template. patch reads the stock
+# cassandra.yaml from conf_yaml_source_file, sets only the keys cassandra-cloud manages (cluster name,
+# ports, addresses, seeds, directories, concurrency) and keeps upstream comments and defaults.
+# Defaults to template.
+# conf_yaml_mode = patch
+# conf_yaml_source_file must differ from conf_yaml_file. When it is missing, the cassandra.yaml found at
+# conf_yaml_file is saved to it first.
+# conf_yaml_source_file = /opt/cassandra/conf/cassandra.yaml.orig
+
+# Templates to generate. Each template block renders source to dest with the same variables and
+# functions as cassandra.yaml. mode and owner are applied to dest, and enabled is true, false or a
+# template condition. The built in templates are cassandra-yaml, cassandra-env, logback, rackdc, topology, jvm-options, jvm-server-options,
+# jvm8-server-options, jvm11-server-options and jvm17-server-options; a block with one of
+# these names changes the fields it sets and keeps the others of the built in template.
+# template "sidecar" {
+#   source = "/opt/cassandra/conf/sidecar-yaml.template"
+#   dest = "/opt/cassandra-sidecar/conf/sidecar.yaml"
+#   mode = "0640"
+#   owner = "cassandra:cassandra"
+#   enabled = "{{ne (env \"SIDECAR_ENABLED\") \"false\"}}"
+# }
+
+# cassandra.yaml keys that are merged into the generated cassandra.yaml. Blocks are merged with the
+# existing maps, other values replace the existing value. Keys can also be set with environment variables,
+# i.e., CASSANDRA_YAML__client_encryption_options__enabled=true, or -yaml-override key.sub=value.
+# yaml_overrides {
+#   compaction_throughput_mb_per_sec = 64
+#   client_encryption_options {
+#     enabled = true
+#     cipher_suites = ["TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"]
+#   }
+# }
 `
 
-func initDefaults(config *Config, logger lg.Logger) {
+func initDefaults(config *Config, logger lg.Logger) error {
+
+	overrideBoolWithEnv("CASSANDRA_KUBERNETES", &config.Kubernetes, logger)
+	if config.Kubernetes {
+		// Without the pod the node would start with localhost addresses and seeds and never join the cluster.
+		if err := initKubernetesProfile(config, logger); err != nil {
+			return fmt.Errorf("Unable to apply the Kubernetes profile: %s", err)
+		}
+	}
 
 	overrideWithEnvOrDefault("CASSANDRA_GC", &config.GC, "AUTO", logger)
 	overrideWithEnvOrDefault("CASSANDRA_G1_PARALLEL_THREADS", &config.G1ParallelGCThreads, "AUTO", logger)
 	overrideWithEnvOrDefault("CASSANDRA_G1_CONCURRENT_THREADS", &config.G1ConcGCThreads, "AUTO", logger)
 	overrideNumberWithEnvOrDefault("CASSANDRA_GC_G1_THRESHOLD_GB", &config.G1ThresholdGBs, 5, logger)
-	overrideWithEnvOrDefault("CASSANDRA_CMS_YOUNG_GEN_SIZE", &config.CmsYoungGenSize, "AUTO", logger)
-	overrideWithEnvOrDefault("CASSANDRA_MAX_HEAP_SIZE", &config.MaxHeapSize, "AUTO", logger)
-	overrideWithEnvOrDefault("CASSANDRA_MIN_HEAP_SIZE", &config.MinHeapSize, "AUTO", logger)
-	overrideWithEnvOrDefault("CASSANDRA_CMS_YOUNG_GEN_SIZE", &config.CmsYoungGenSize, "AUTO", logger)
-
-	config.GC = strings.ToUpper(config.GC)
-	config.G1ParallelGCThreads = strings.ToUpper(config.G1ParallelGCThreads)
-	config.G1ConcGCThreads = strings.ToUpper(config.G1ConcGCThreads)
-	config.CmsYoungGenSize = strings.ToUpper(config.CmsYoungGenSize)
-	config.MinHeapSize = strings.ToUpper(config.MinHeapSize)
-	config.MaxHeapSize = strings.ToUpper(config.MaxHeapSize)
-
-	config = gcErgonomics(config, logger)
+	overrideWithEnvOrDefault("CASSANDRA_GC_LOW_PAUSE", &config.LowPauseGC, GCZGC, logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_GC_LOW_PAUSE_THRESHOLD_GBS", &config.LowPauseThresholdGBs, 24, logger)
+	overrideWithEnvOrDefault("CASSANDRA_ZGC_CONCURRENT_THREADS", &config.ZGCConcGCThreads, "AUTO", logger)
+	overrideSizeWithEnvOrDefault("CASSANDRA_ZGC_SOFT_MAX_HEAP_SIZE", &config.ZGCSoftMaxHeapSize, AutoSize, logger)
+	overrideWithEnvOrDefault("CASSANDRA_SHENANDOAH_HEURISTICS", &config.ShenandoahHeuristics, "adaptive", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_JAVA_MAJOR_VERSION", &config.JavaMajorVersion, 0, logger)
+	overrideWithEnvOrDefault("CASSANDRA_JAVA_HOME", &config.JavaHome, "", logger)
+	overrideSizeWithEnvOrDefault("CASSANDRA_CMS_YOUNG_GEN_SIZE", &config.CmsYoungGenSize, AutoSize, logger)
+	overrideSizeWithEnvOrDefault("CASSANDRA_MAX_HEAP_SIZE", &config.MaxHeapSize, AutoSize, logger)
+	overrideSizeWithEnvOrDefault("CASSANDRA_MIN_HEAP_SIZE", &config.MinHeapSize, AutoSize, logger)
+
+	overrideWithEnvOrDefault("CASSANDRA_MEMORY_BASIS", &config.MemoryBasis, MemoryBasisCgroupLimit, logger)
+	overrideWithEnvOrDefault("CASSANDRA_SYSTEM_ROOT", &config.SystemRoot, "/", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_CPU_COUNT", &config.CpuCount, 0, logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_DATA_DISK_COUNT", &config.DataDiskCount, 0, logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONCURRENT_READS", &config.ConcurrentReads, "AUTO", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONCURRENT_WRITES", &config.ConcurrentWrites, "AUTO", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONCURRENT_COUNTER_WRITES", &config.ConcurrentCounterWrites, "AUTO", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONCURRENT_MATERIALIZED_VIEW_WRITES", &config.ConcurrentMaterializedViewWrites,
+		"AUTO", logger)
+	overrideWithEnvOrDefault("CASSANDRA_HEAP_POLICY", &config.HeapPolicy, "percent:70", logger)
 
 	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_NAME", &config.ClusterName, "mycluster", logger)
 	overrideWithEnvOrDefault("CASSANDRA_HOME_DIR", &config.CassandraHome, "/opt/cassandra", logger)
@@ -229,15 +666,101 @@ func initDefaults(config *Config, logger lg.Logger) {
 		config.CassandraHome+"/conf/cassandra-yaml.template", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CONF_YAML_FILE", &config.YamlConfigFileName,
 		config.CassandraHome+"/conf/cassandra.yaml", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_YAML_MODE", &config.YamlMode, "template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_YAML_SOURCE_FILE", &config.YamlSourceFileName,
+		config.CassandraHome+"/conf/cassandra.yaml.orig", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM_OPTIONS_TEMPLATE", &config.JvmOptionsTemplate,
 		config.CassandraHome+"/conf/jvm-options.template", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM_OPTIONS_FILE", &config.JvmOptionsFileName,
 		config.CassandraHome+"/conf/jvm.options", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_CASSANDRA_ENV_TEMPLATE", &config.CassandraEnvTemplate,
+		config.CassandraHome+"/conf/cassandra-env.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_CASSANDRA_ENV_FILE", &config.CassandraEnvFileName,
+		config.CassandraHome+"/conf/cassandra-env.sh", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_JMX_PORT", &config.JmxPort, 7199, logger)
+	overrideWithEnvOrDefault("CASSANDRA_JMX_HOST", &config.JmxHost, "localhost", logger)
+	overrideBoolWithEnv("CASSANDRA_JMX_AUTHENTICATE", &config.JmxAuthenticate, logger)
+	overrideWithEnvOrDefault("CASSANDRA_JMX_PASSWORD_FILE", &config.JmxPasswordFile,
+		"/etc/cassandra/jmxremote.password", logger)
+	overrideWithEnvOrDefault("CASSANDRA_LOG_DIR", &config.LogDir, config.CassandraHome+"/logs", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_LOGBACK_TEMPLATE", &config.LogbackTemplate,
+		config.CassandraHome+"/conf/logback.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_LOGBACK_FILE", &config.LogbackFileName,
+		config.CassandraHome+"/conf/logback.xml", logger)
+	overrideWithEnvOrDefault("CASSANDRA_LOG_LEVEL", &config.LogLevel, "INFO", logger)
+	if envValue := os.Getenv("CASSANDRA_LOG_LEVELS"); envValue != "" {
+		logger.Debug("Using", "CASSANDRA_LOG_LEVELS", "to override", "value=", envValue)
+		if err := config.LogLevels.Set(envValue); err != nil {
+			logger.ErrorError("Unable to use CASSANDRA_LOG_LEVELS", err)
+		}
+	}
+	overrideSizeWithEnvOrDefault("CASSANDRA_LOG_MAX_FILE_SIZE", &config.LogMaxFileSize, "50MB", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_LOG_MAX_HISTORY", &config.LogMaxHistory, 7, logger)
+	overrideSizeWithEnvOrDefault("CASSANDRA_LOG_TOTAL_SIZE_CAP", &config.LogTotalSizeCap, "5GB", logger)
+	overrideWithEnvOrDefault("CASSANDRA_LOG_FORMAT", &config.LogFormat, LogFormatPattern, logger)
+	overrideWithEnvOrDefault("CASSANDRA_LOG_JSON_ENCODER", &config.LogJsonEncoder,
+		"net.logstash.logback.encoder.LogstashEncoder", logger)
+	if envValue := os.Getenv("CASSANDRA_EXTRA_JVM_OPTS"); envValue != "" {
+		logger.Debug("Using", "CASSANDRA_EXTRA_JVM_OPTS", "to override", "value=", envValue)
+		config.ExtraJvmOpts = strings.Fields(envValue)
+	}
+	overrideWithEnvOrDefault("CASSANDRA_CASSANDRA_VERSION", &config.CassandraVersion, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM_OPTIONS_LAYOUT", &config.JvmOptionsLayout, "AUTO", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM_SERVER_OPTIONS_TEMPLATE", &config.JvmServerOptionsTemplate,
+		config.CassandraHome+"/conf/jvm-server-options.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM_SERVER_OPTIONS_FILE", &config.JvmServerOptionsFileName,
+		config.CassandraHome+"/conf/jvm-server.options", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM8_SERVER_OPTIONS_TEMPLATE", &config.Jvm8ServerOptionsTemplate,
+		config.CassandraHome+"/conf/jvm8-server-options.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM8_SERVER_OPTIONS_FILE", &config.Jvm8ServerOptionsFileName,
+		config.CassandraHome+"/conf/jvm8-server.options", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM11_SERVER_OPTIONS_TEMPLATE", &config.Jvm11ServerOptionsTemplate,
+		config.CassandraHome+"/conf/jvm11-server-options.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM11_SERVER_OPTIONS_FILE", &config.Jvm11ServerOptionsFileName,
+		config.CassandraHome+"/conf/jvm11-server.options", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM17_SERVER_OPTIONS_TEMPLATE", &config.Jvm17ServerOptionsTemplate,
+		config.CassandraHome+"/conf/jvm17-server-options.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_JVM17_SERVER_OPTIONS_FILE", &config.Jvm17ServerOptionsFileName,
+		config.CassandraHome+"/conf/jvm17-server.options", logger)
 
 	overrideWithEnvOrDefault("CASSANDRA_SNITCH", &config.Snitch, "SimpleSnitch", logger)
+	overrideWithEnvOrDefault("CASSANDRA_DATACENTER", &config.Datacenter, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_RACK", &config.Rack, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_DC_SUFFIX", &config.DcSuffix, "", logger)
+	overrideBoolWithEnv("CASSANDRA_PREFER_LOCAL", &config.PreferLocal, logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_RACKDC_TEMPLATE", &config.RackDCTemplate,
+		config.CassandraHome+"/conf/cassandra-rackdc.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_RACKDC_FILE", &config.RackDCFileName,
+		config.CassandraHome+"/conf/cassandra-rackdc.properties", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_TOPOLOGY_TEMPLATE", &config.TopologyTemplate,
+		config.CassandraHome+"/conf/cassandra-topology.template", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONF_TOPOLOGY_FILE", &config.TopologyFileName,
+		config.CassandraHome+"/conf/cassandra-topology.properties", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_SEEDS", &config.ClusterSeeds, "127.0.0.1", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_CLUSTER_SEEDS_MAX_COUNT", &config.SeedsMaxCount, 0, logger)
+	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_SEEDS_RESOLVER", &config.SeedsResolver, "", logger)
+	if envValue := os.Getenv("CASSANDRA_EC2_SEED_TAGS"); envValue != "" {
+		logger.Debug("Using", "CASSANDRA_EC2_SEED_TAGS", "to override", "value=", envValue)
+		if err := config.Ec2SeedTags.Set(envValue); err != nil {
+			logger.ErrorError("Unable to use CASSANDRA_EC2_SEED_TAGS", err)
+		}
+	}
+	overrideWithEnvOrDefault("CASSANDRA_EC2_REGION", &config.Ec2Region, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_EC2_ENDPOINT", &config.Ec2Endpoint, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONSUL_ADDRESS", &config.ConsulAddress, ConsulAddress, logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONSUL_TOKEN", &config.ConsulToken, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONSUL_SERVICE", &config.ConsulService, "cassandra", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CONSUL_SEED_TAG", &config.ConsulSeedTag, "", logger)
+	overrideBoolWithEnv("CASSANDRA_CONSUL_REGISTER", &config.ConsulRegister, logger)
+	if envValue := os.Getenv("CASSANDRA_CONSUL_TAGS"); envValue != "" {
+		logger.Debug("Using", "CASSANDRA_CONSUL_TAGS", "to override", "value=", envValue)
+		config.ConsulTags = strings.Split(envValue, ",")
+	}
 	overrideWithEnvOrDefault("CASSANDRA_CLIENT_INTERFACE", &config.ClientListenInterface, "", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CLIENT_ADDRESS", &config.ClientListenAddress, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_CLIENT_BROADCAST_ADDRESS", &config.ClientBroadcastAddress, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_INTERFACE_IP_PREFERENCE", &config.InterfaceIPPreference, IPPreferenceIPv4, logger)
+	overrideBoolWithEnv("CASSANDRA_RESOLVE_INTERFACES", &config.ResolveInterfaces, logger)
 	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_INTERFACE", &config.ClusterListenInterface, "", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_ADDRESS", &config.ClusterListenAddress, "", logger)
 	overrideWithEnvOrDefault("CASSANDRA_CLUSTER_BROADCAST_ADDRESS", &config.ClusterBroadcastAddress, "", logger)
@@ -251,104 +774,258 @@ func initDefaults(config *Config, logger lg.Logger) {
 	overrideNumberWithEnvOrDefault("CASSANDRA_CLUSTER_SSL_PORT", &config.ClusterSslPort, 7001, logger)
 	overrideNumberWithEnvOrDefault("CASSANDRA_CLIENT_PORT", &config.ClientPort, 9042, logger)
 
-	if config.ClientListenAddress != "" && config.ClientListenInterface != "" {
-		logger.Error("The client listen address and the client listen interface can't both be set")
-	} else if config.ClientListenAddress == "" && config.ClientListenInterface == "" {
-		logger.Debug("ClientListenAddress and ClientListenInterface were not set, setting to localhost")
-		config.ClientListenAddress = "localhost"
+	initYamlOverrides(config, logger)
+	return nil
+}
+
+func initVersions(config *Config, logger lg.Logger) {
+	if config.CassandraVersion == "" {
+		version, err := DetectCassandraVersion(config.CassandraHome)
+		if err != nil {
+			logger.ErrorError("Unable to detect the Cassandra version, defaulting to 3.11", err)
+			version = "3.11"
+		}
+		logger.Debug("Cassandra version", version)
+		config.CassandraVersion = version
 	}
-	if config.ClusterListenAddress != "" && config.ClusterListenInterface != "" {
-		logger.Error("The cluster listen address and the cluster listen interface can't both be set")
-	} else if config.ClusterListenAddress == "" && config.ClusterListenInterface == "" {
-		logger.Debug("ClusterListenAddress and ClusterListenInterface were not set, setting to localhost")
-		config.ClusterListenAddress = "localhost"
+
+	config.JvmOptionsLayout = strings.ToLower(config.JvmOptionsLayout)
+	if config.JvmOptionsLayout == "auto" {
+		config.JvmOptionsLayout = LookupTemplateSet(config.CassandraVersion).JvmOptionsLayout
 	}
 
-	initYamlTemplate(config.YamlConfigTemplate, logger)
+	if config.JavaMajorVersion <= 0 {
+		config.JavaMajorVersion = detectJavaMajorVersion(config, logger)
+	}
+}
+
+// initTemplates creates missing templates from the template set that matches the Cassandra version.
+func initTemplates(config *Config, logger lg.Logger) {
+	templateSet := LookupTemplateSet(config.CassandraVersion)
+	logger.Debug("Using the Cassandra", templateSet.Version, "template set")
+
+	config.YamlMode = strings.ToLower(config.YamlMode)
+	if config.YamlMode != "patch" {
+		initYamlTemplate(config.YamlConfigTemplate, templateSet.Yaml, logger)
+	}
 
 	initJvmOptionsTemplate(config.JvmOptionsTemplate, logger)
 
+	initCassandraEnvTemplate(config.CassandraEnvTemplate, logger)
+
+	initLogbackTemplate(config.LogbackTemplate, logger)
+
+	initRackDCTemplate(config.RackDCTemplate, logger)
+
+	initTopologyTemplate(config.TopologyTemplate, logger)
+
+	initJvmServerOptionsTemplates(config, logger)
+
+	initTemplateManifest(config, logger)
 }
-func gcErgonomics(config *Config, logger lg.Logger) *Config {
-	if config.G1ParallelGCThreads == "AUTO" {
-		if runtime.NumCPU() > 10 {
-			config.G1ParallelGCThreads = strconv.Itoa(runtime.NumCPU() - 1)
-		} else {
-			config.G1ParallelGCThreads = strconv.Itoa(runtime.NumCPU())
+
+// initErgonomics runs after the command line is bound so flags can set AUTO values and the memory basis.
+func initErgonomics(config *Config, logger lg.Logger) error {
+	config.GC = strings.ToUpper(config.GC)
+	config.G1ParallelGCThreads = strings.ToUpper(config.G1ParallelGCThreads)
+	config.G1ConcGCThreads = strings.ToUpper(config.G1ConcGCThreads)
+	config.LowPauseGC = strings.ToUpper(config.LowPauseGC)
+	config.ZGCConcGCThreads = strings.ToUpper(config.ZGCConcGCThreads)
+	config.ShenandoahHeuristics = strings.ToLower(config.ShenandoahHeuristics)
+	sizes := []struct {
+		name  string
+		value *Size
+	}{
+		{"zgc_soft_max_heap_size", &config.ZGCSoftMaxHeapSize},
+		{"cms_young_gen_size", &config.CmsYoungGenSize},
+		{"min_heap_size", &config.MinHeapSize},
+		{"max_heap_size", &config.MaxHeapSize},
+	}
+	for _, size := range sizes {
+		if err := normalizeSize(size.name, size.value); err != nil {
+			return err
 		}
 	}
-	if config.G1ConcGCThreads == "AUTO" {
-		config.G1ConcGCThreads = config.G1ParallelGCThreads
+	if err := initLogging(config, logger); err != nil {
+		return err
 	}
-	if config.CmsYoungGenSize == "AUTO" {
-		config.CmsYoungGenSize = strconv.Itoa(runtime.NumCPU()) + "00m"
+	config.MemoryBasis = strings.ToLower(config.MemoryBasis)
+	config.HeapPolicy = strings.ToLower(config.HeapPolicy)
+
+	if config.CpuCount <= 0 {
+		config.CpuCount = GetCPUCount(config.SystemRoot)
+		logger.Debug("Effective CPU count", config.CpuCount)
 	}
 
-	memSize := getMemory(logger)
+	config.ConcurrentReads = strings.ToUpper(strings.TrimSpace(config.ConcurrentReads))
+	config.ConcurrentWrites = strings.ToUpper(strings.TrimSpace(config.ConcurrentWrites))
+	config.ConcurrentCounterWrites = strings.ToUpper(strings.TrimSpace(config.ConcurrentCounterWrites))
+	config.ConcurrentMaterializedViewWrites = strings.ToUpper(strings.TrimSpace(config.ConcurrentMaterializedViewWrites))
 
-	if config.MaxHeapSize == "AUTO" {
-		maxHeapSize := memSize * 7 / 10
-		config.MaxHeapSize = strconv.FormatUint( maxHeapSize / 1000000, 10 ) + "m"
-	}
-	if config.MinHeapSize == "AUTO" {
-		config.MinHeapSize = config.MaxHeapSize
+	if config.DataDiskCount <= 0 {
+		config.DataDiskCount = CountDataDisks(config.DataDirs)
+		logger.Debug("Data disk count", config.DataDiskCount)
 	}
-	if config.GC == "AUTO" {
-		actualGB := int(memSize / 1000000000)
 
-		if actualGB  > config.G1ThresholdGBs {
-			config.GC = "G1"
-		} else {
-			config.GC = "CMS"
+	gcErgonomics(config, logger)
+	return concurrencyErgonomics(config, logger)
+}
+
+// concurrencyErgonomics follows the cassandra.yaml guidance: reads and counter writes are IO bound so
+// use 16 * data disks, writes and materialized view writes are CPU bound so use 8 * CPUs.
+// Values that are not AUTO must be a positive number, cassandra.yaml is patched with them as is.
+func concurrencyErgonomics(config *Config, logger lg.Logger) error {
+	if config.ConcurrentReads == "AUTO" {
+		config.ConcurrentReads = strconv.Itoa(16 * config.DataDiskCount)
+	}
+	if config.ConcurrentCounterWrites == "AUTO" {
+		config.ConcurrentCounterWrites = strconv.Itoa(16 * config.DataDiskCount)
+	}
+	if config.ConcurrentWrites == "AUTO" {
+		config.ConcurrentWrites = strconv.Itoa(8 * config.CpuCount)
+	}
+	if config.ConcurrentMaterializedViewWrites == "AUTO" {
+		config.ConcurrentMaterializedViewWrites = strconv.Itoa(8 * config.CpuCount)
+	}
+	settings := []struct {
+		name  string
+		value string
+	}{
+		{"concurrent_reads", config.ConcurrentReads},
+		{"concurrent_writes", config.ConcurrentWrites},
+		{"concurrent_counter_writes", config.ConcurrentCounterWrites},
+		{"concurrent_materialized_view_writes", config.ConcurrentMaterializedViewWrites},
+	}
+	for _, setting := range settings {
+		if count, err := strconv.Atoi(setting.value); err != nil || count <= 0 {
+			return fmt.Errorf("%s must be AUTO or a positive number, not %q", setting.name, setting.value)
 		}
 	}
-	return config
+	logger.Debug("Concurrent reads", config.ConcurrentReads, "writes", config.ConcurrentWrites,
+		"counter writes", config.ConcurrentCounterWrites, "view writes", config.ConcurrentMaterializedViewWrites)
+	return nil
 }
 
-func getMemory(logger lg.Logger) uint64 {
-	memSize,err := GetMemory()
-	if err != nil {
-		logger.ErrorError("Unable to get memory size defaulting to 5GB heap", err)
-		memSize = 5000000000
-	} else {
-		memSize = memSize * 1000
+func detectJavaMajorVersion(config *Config, logger lg.Logger) int {
+	javaHome, err := FindJavaHome(config.JavaHome, config.CassandraHome)
+	if err == nil {
+		config.JavaHome = javaHome
+		var version int
+		version, err = ReadJavaMajorVersion(javaHome)
+		if err == nil {
+			logger.Debug("Detected JDK", version, "in", javaHome)
+			return version
+		}
 	}
-	return memSize
+	logger.ErrorError("Unable to detect the JDK version, defaulting to 8", err)
+	return 8
 }
 
+func gcErgonomics(config *Config, logger lg.Logger) *Config {
+	cpuCount := config.CpuCount
+	if config.G1ParallelGCThreads == "AUTO" {
+		if cpuCount > 10 {
+			config.G1ParallelGCThreads = strconv.Itoa(cpuCount - 1)
+		} else {
+			config.G1ParallelGCThreads = strconv.Itoa(cpuCount)
+		}
+	}
+	if config.G1ConcGCThreads == "AUTO" {
+		config.G1ConcGCThreads = config.G1ParallelGCThreads
+	}
 
+	memSize := getMemory(config, logger)
 
-func  GetMemory() (uint64, error) {
+	heapErgonomics(config, memSize, logger)
 
-	var output string
+	if config.MinHeapSize.IsAuto() {
+		config.MinHeapSize = config.MaxHeapSize
+	}
+	validateHeapSizes(config, memSize, logger)
+	selectGC(config, memSize, logger)
+	return config
+}
 
-	var command string
-	if runtime.GOOS == "linux" {
-		command = "/usr/bin/free"
-	} else if runtime.GOOS == "darwin" {
-		command = "/usr/local/bin/free"
+// heapErgonomics sizes the heap and young gen with the heap policy and keeps the heap under the compressed oops ceiling.
+// An explicit max_heap_size is used as is, which is what the fixed policy requires, with a warning when it is over the ceiling.
+func heapErgonomics(config *Config, memSize uint64, logger lg.Logger) {
+	if config.HeapPolicy == HeapPolicyFixed && config.MaxHeapSize.IsAuto() {
+		logger.Error("The fixed heap policy needs max_heap_size to be set, using", HeapPolicyCassandraDefault)
+		config.HeapPolicy = HeapPolicyCassandraDefault
 	}
-	if out, err := exec.Command(command).Output(); err != nil {
-		return 0, err
+
+	var sizes HeapSizes
+	if !config.MaxHeapSize.IsAuto() {
+		maxHeap := config.MaxHeapSize.Bytes()
+		sizes = HeapSizes{MaxHeap: maxHeap, YoungGen: youngGenSize(maxHeap, config.CpuCount)}
 	} else {
-		output = string(out)
+		policy, err := LookupHeapPolicy(config.HeapPolicy)
+		if err != nil {
+			logger.ErrorError("Unable to use heap policy, using "+HeapPolicyCassandraDefault, err)
+			policy = cassandraDefaultHeapPolicy
+		}
+		sizes = policy(memSize, config.CpuCount)
 	}
 
-	lines := strings.Split(output, "\n")
-	line1 := lines[1]
+	if sizes.MaxHeap > CompressedOopsCeiling {
+		if config.MaxHeapSize.IsAuto() {
+			logger.Debug("Heap size", SizeOf(sizes.MaxHeap), "is over the compressed oops ceiling, capping it at 31g")
+			sizes = HeapSizes{MaxHeap: CompressedOopsCeiling, YoungGen: youngGenSize(CompressedOopsCeiling, config.CpuCount)}
+		} else {
+			logger.Error("max_heap_size", config.MaxHeapSize, "is over the compressed oops ceiling of 31g,",
+				"the JVM will not use compressed oops")
+		}
+	}
 
-	var total uint64
-	var free uint64
-	var used uint64
-	var shared uint64
-	var buffer uint64
-	var available uint64
-	var mem string
+	if config.MaxHeapSize.IsAuto() {
+		config.MaxHeapSize = SizeOf(sizes.MaxHeap / Mebibyte * Mebibyte)
+	}
+	if config.CmsYoungGenSize.IsAuto() {
+		config.CmsYoungGenSize = SizeOf(sizes.YoungGen / Mebibyte * Mebibyte)
+	}
+	logger.Debug("Heap policy", config.HeapPolicy, "MaxHeapSize", config.MaxHeapSize,
+		"CmsYoungGenSize", config.CmsYoungGenSize)
+}
 
-	fmt.Sscanf(line1, "%s %d %d %d %d %d %d", &mem, &total, &used, &free, &shared, &buffer, &available)
+// validateHeapSizes checks that young gen < min heap <= max heap <= memory and fixes what it can.
+func validateHeapSizes(config *Config, memSize uint64, logger lg.Logger) {
+	if config.MinHeapSize.Bytes() > config.MaxHeapSize.Bytes() {
+		logger.Error("min_heap_size", config.MinHeapSize, "is larger than max_heap_size", config.MaxHeapSize,
+			"using max_heap_size for both")
+		config.MinHeapSize = config.MaxHeapSize
+	}
+	if config.MaxHeapSize.Bytes() > memSize {
+		logger.Error("max_heap_size", config.MaxHeapSize, "is larger than the memory size", SizeOf(memSize))
+	}
+	if config.CmsYoungGenSize.Bytes() >= config.MaxHeapSize.Bytes() {
+		logger.Error("cms_young_gen_size", config.CmsYoungGenSize, "must be smaller than max_heap_size",
+			config.MaxHeapSize)
+	}
+}
 
-	return free, nil
+// normalizeSize parses a size from cloud.conf, the environment or the command line. An invalid or zero size is an
+// error, it would otherwise end up as an invalid JVM option such as -Xmx0.
+func normalizeSize(name string, value *Size) error {
+	size, err := ParseSize(string(*value))
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s", name, err)
+	}
+	if !size.IsAuto() && size.Bytes() == 0 {
+		return fmt.Errorf("Invalid %s %s, expected AUTO or a size larger than 0", name, *value)
+	}
+	*value = size
+	return nil
+}
 
+func getMemory(config *Config, logger lg.Logger) uint64 {
+	memSize, err := GetMemory(config.SystemRoot, config.MemoryBasis)
+	if err != nil {
+		logger.ErrorError("Unable to get memory size defaulting to 5GB heap", err)
+		memSize = 5000000000
+	} else {
+		logger.Debug("Memory size using basis", config.MemoryBasis, "is", memSize)
+	}
+	return memSize
 }
 
 func overrideWithEnvOrDefault(envName string, value *string, defaultValue string, logger lg.Logger) {
@@ -365,6 +1042,30 @@ func overrideWithEnvOrDefault(envName string, value *string, defaultValue string
 	}
 }
 
+func overrideSizeWithEnvOrDefault(envName string, value *Size, defaultValue Size, logger lg.Logger) {
+	envValue := os.Getenv(envName)
+	if envValue != "" {
+		logger.Debug("Using", envName, "to override", "value=", envValue)
+		*value = Size(envValue)
+	}
+	if *value == "" {
+		*value = defaultValue
+	}
+}
+
+func overrideBoolWithEnv(envName string, value *bool, logger lg.Logger) {
+	envValue := os.Getenv(envName)
+	if envValue != "" {
+		logger.Debug("Using", envName, "to override", "value=", envValue)
+		parsed, err := strconv.ParseBool(envValue)
+		if err != nil {
+			logger.ErrorError("Unable to parse "+envName, err)
+			return
+		}
+		*value = parsed
+	}
+}
+
 func overrideNumberWithEnvOrDefault(envName string, value *int, defaultValue int, logger lg.Logger) {
 	envValue := os.Getenv(envName)
 	if envValue != "" {
@@ -389,12 +1090,48 @@ func bindCommandlineArgs(config *Config, logger lg.Logger) {
 	flag.StringVar(&config.ClusterSeeds, "cluster-seeds", config.ClusterSeeds,
 		"Comma delimited list of initial clustrer contact points for bootstrapping")
 
+	flag.IntVar(&config.SeedsMaxCount, "cluster-seeds-max-count", config.SeedsMaxCount,
+		"Maximum number of seeds written to cassandra.yaml. 0 writes all of them.")
+
+	flag.StringVar(&config.SeedsResolver, "cluster-seeds-resolver", config.SeedsResolver,
+		"DNS server, host:port, used for the dns: and srv: seeds. Defaults to the resolver of the system.")
+
+	flag.Var(&config.Ec2SeedTags, "ec2-seed-tags",
+		"Tags of the instances used by ec2: seeds, i.e., cassandra-cluster=prod,cassandra-seed=true")
+
+	flag.StringVar(&config.Ec2Region, "ec2-region", config.Ec2Region,
+		"Region for ec2: seeds. Defaults to the region from EC2 discovery, then AWS_REGION.")
+
+	flag.StringVar(&config.Ec2Endpoint, "ec2-endpoint", config.Ec2Endpoint,
+		"EC2 API endpoint. Defaults to https://ec2.<region>.amazonaws.com.")
+
+	flag.StringVar(&config.ConsulAddress, "consul-address", config.ConsulAddress,
+		"HTTP API of the Consul agent used by consul: seeds and consul-register")
+
+	flag.StringVar(&config.ConsulToken, "consul-token", config.ConsulToken, "Consul ACL token")
+
+	flag.StringVar(&config.ConsulService, "consul-service", config.ConsulService, "Consul service of the Cassandra nodes")
+
+	flag.StringVar(&config.ConsulSeedTag, "consul-seed-tag", config.ConsulSeedTag,
+		"Only use the instances with this tag as consul: seeds")
+
+	flag.BoolVar(&config.ConsulRegister, "consul-register", config.ConsulRegister,
+		"Register this node with the Consul agent")
+
+	consulTags := flag.String("consul-tags", "", "Comma delimited tags of the Consul service instance of this node")
+
 	flag.StringVar(&config.ClusterListenAddress, "cluster-address", config.ClusterListenAddress,
 		"Cluster address for inter-node communication. Example: 192.43.32.10, localhost, etc.")
 
-	flag.StringVar(&config.ClusterBroadcastAddress, "cluster-broadcast-address", config.ClusterListenAddress,
+	flag.StringVar(&config.ClusterBroadcastAddress, "cluster-broadcast-address", config.ClusterBroadcastAddress,
 		"Cluster address for cross region communication. Example: 55.43.32.10, etc.")
 
+	flag.StringVar(&config.Discovery, "discovery", config.Discovery,
+		"Cloud metadata service used to fill in the addresses, data center and rack that are not set. Values: none, ec2, gce, azure, configdrive, nocloud")
+
+	flag.StringVar(&config.DiscoveryEndpoint, "discovery-endpoint", config.DiscoveryEndpoint,
+		"Base URL of the metadata service, or the directory for configdrive and nocloud. Empty uses the default endpoint of the provider.")
+
 	flag.StringVar(&config.ClusterListenInterface, "cluster-interface", config.ClusterListenInterface,
 		"Cluster interface for inter-node communication.  Example: eth0, eth1, etc.")
 
@@ -402,6 +1139,15 @@ func bindCommandlineArgs(config *Config, logger lg.Logger) {
 		"Client address for client driver communication. Example: 192.43.32.10, localhost, etc.")
 
 
+	flag.StringVar(&config.InterfaceIPPreference, "interface-ip-preference", config.InterfaceIPPreference,
+		"IP version used for cluster-interface and client-interface when they have both. Values: ipv4, ipv6")
+
+	flag.BoolVar(&config.ResolveInterfaces, "resolve-interfaces", config.ResolveInterfaces,
+		"Write the address of the cluster and client interfaces instead of the interface names")
+
+	flag.StringVar(&config.ClientBroadcastAddress, "client-broadcast-address", config.ClientBroadcastAddress,
+		"Address clients are told to connect to. Required when client-address is 0.0.0.0.")
+
 	flag.StringVar(&config.ReplaceAddress, "-replace-address", config.ReplaceAddress,
 		"Replace address used to replace a Cassandra node that has failed or is being replaced.")
 
@@ -411,18 +1157,134 @@ func bindCommandlineArgs(config *Config, logger lg.Logger) {
 	flag.StringVar(&config.Snitch, "snitch", config.Snitch,
 		"Snitch type. Example: GossipingPropertyFileSnitch, PropertyFileSnitch, Ec2Snitch, etc.")
 
+	flag.StringVar(&config.Datacenter, "datacenter", config.Datacenter,
+		"Data center of this node for GossipingPropertyFileSnitch")
+
+	flag.StringVar(&config.Rack, "rack", config.Rack, "Rack of this node for GossipingPropertyFileSnitch")
+
+	flag.StringVar(&config.DcSuffix, "dc-suffix", config.DcSuffix, "Suffix added to the data center name")
+
+	flag.BoolVar(&config.PreferLocal, "prefer-local", config.PreferLocal,
+		"Prefer the internal IP to talk to nodes in the same data center")
+
+	flag.StringVar(&config.RackDCTemplate, "conf-rackdc-template", config.RackDCTemplate,
+		"Location of cassandra-rackdc.properties template")
+
+	flag.StringVar(&config.RackDCFileName, "conf-rackdc-file", config.RackDCFileName,
+		"Location of cassandra-rackdc.properties")
+
+	flag.StringVar(&config.TopologyTemplate, "conf-topology-template", config.TopologyTemplate,
+		"Location of cassandra-topology.properties template")
+
+	flag.StringVar(&config.TopologyFileName, "conf-topology-file", config.TopologyFileName,
+		"Location of cassandra-topology.properties")
+
 	flag.StringVar(&config.GC, "gc", config.GC,
-		"GC type. Values: CMS, G1, or AUTO. If you set to AUTO, if heap is bigger than 5 GB (gc-g1-threshold-gbs), G1 is used, otherwise CMS.")
+		"GC type. Values: CMS, G1, ZGC, GENERATIONAL_ZGC, SHENANDOAH or AUTO. If you set to AUTO, if heap is bigger than gc-low-pause-threshold-gbs on JDK 15+, gc-low-pause is used, if memory is bigger than 5 GB (gc-g1-threshold-gbs), G1 is used, otherwise CMS.")
 
 	flag.IntVar(&config.G1ThresholdGBs, "gc-g1-threshold-gbs", config.G1ThresholdGBs,
 		"GC threshold switch. Defaults to 5 GB. If gc set to AUTO, if heap is bigger than gc-g1-threshold-gbs, G1 is used, otherwise CMS.")
 
+	flag.StringVar(&config.LowPauseGC, "gc-low-pause", config.LowPauseGC,
+		"Low pause collector used by AUTO for big heaps on JDK 15+. Values: ZGC (GENERATIONAL_ZGC on JDK 21+) or SHENANDOAH.")
+
+	flag.IntVar(&config.LowPauseThresholdGBs, "gc-low-pause-threshold-gbs", config.LowPauseThresholdGBs,
+		"Heap size in GB at which gc AUTO uses the low pause collector. Defaults to 24 GB.")
+
+	flag.StringVar(&config.ZGCConcGCThreads, "zgc-concurrent-threads", config.ZGCConcGCThreads,
+		"The count of ZGC concurrent threads. Values: AUTO, or some number. AUTO uses a quarter of the CPUs")
+
+	flag.Var(&config.ZGCSoftMaxHeapSize, "zgc-soft-max-heap-size",
+		"Soft heap limit ZGC tries to stay under, i.e., 20GB. AUTO leaves it unset.")
+
+	flag.StringVar(&config.ShenandoahHeuristics, "shenandoah-heuristics", config.ShenandoahHeuristics,
+		"Shenandoah heuristics. Values: adaptive, static, compact or aggressive.")
+
+	flag.IntVar(&config.JavaMajorVersion, "java-major-version", config.JavaMajorVersion,
+		"Major version of the JDK used to pick GC and JVM options, i.e., 8, 11 or 17. 0 reads the release file of the JDK.")
+
+	flag.StringVar(&config.JavaHome, "java-home", config.JavaHome,
+		"JDK home used to detect the JDK version. Defaults to JAVA_HOME, then the jdk, jre or java directory under home_dir.")
+
+	flag.StringVar(&config.CassandraEnvTemplate, "conf-cassandra-env-template", config.CassandraEnvTemplate,
+		"Location of cassandra-env.sh template")
+
+	flag.StringVar(&config.CassandraEnvFileName, "conf-cassandra-env-file", config.CassandraEnvFileName,
+		"Location of cassandra-env.sh")
+
+	flag.IntVar(&config.JmxPort, "jmx-port", config.JmxPort, "JMX port")
+
+	flag.StringVar(&config.JmxHost, "jmx-host", config.JmxHost,
+		"JMX host. localhost only allows local JMX, any other host enables remote JMX")
+
+	flag.BoolVar(&config.JmxAuthenticate, "jmx-authenticate", config.JmxAuthenticate,
+		"Remote JMX checks jmx-password-file. Defaults to true")
+
+	flag.StringVar(&config.JmxPasswordFile, "jmx-password-file", config.JmxPasswordFile, "Password file of remote JMX")
+
+	flag.StringVar(&config.LogDir, "log-dir", config.LogDir, "Directory for the Cassandra and GC logs")
+
+	flag.StringVar(&config.LogbackTemplate, "conf-logback-template", config.LogbackTemplate,
+		"Location of logback.xml template")
+
+	flag.StringVar(&config.LogbackFileName, "conf-logback-file", config.LogbackFileName,
+		"Location of logback.xml")
+
+	flag.StringVar(&config.LogLevel, "log-level", config.LogLevel,
+		"Root log level. Values: TRACE, DEBUG, INFO, WARN, ERROR, OFF")
+
+	flag.Var(&config.LogLevels, "log-levels",
+		"Logger levels, i.e., org.apache.cassandra.db=DEBUG,org.apache.cassandra.gms=WARN")
+
+	flag.Var(&config.LogMaxFileSize, "log-max-file-size", "Size a log file rolls over at, i.e., 50MB")
+
+	flag.IntVar(&config.LogMaxHistory, "log-max-history", config.LogMaxHistory, "Days of logs to keep")
+
+	flag.Var(&config.LogTotalSizeCap, "log-total-size-cap", "Total size of the logs to keep, i.e., 5GB")
+
+	flag.StringVar(&config.LogFormat, "log-format", config.LogFormat, "Log format. Values: pattern, json")
+
+	flag.StringVar(&config.LogJsonEncoder, "log-json-encoder", config.LogJsonEncoder,
+		"Logback encoder class used by the json log format")
+
+	extraJvmOpts := flag.String("extra-jvm-opts", "", "Extra JVM options separated by spaces")
+
 	flag.StringVar(&config.JvmOptionsTemplate, "conf-jvm-options-template", config.JvmOptionsTemplate,
 		"JVM Option template location. Used to generate the jvm.options file using system ergonomics.")
 
 	flag.StringVar(&config.JvmOptionsFileName, "conf-jvm-options-file", config.JvmOptionsFileName,
 		"JVM Option location which will be overwritten with template.")
 
+	flag.StringVar(&config.JvmOptionsLayout, "conf-jvm-options-layout", config.JvmOptionsLayout,
+		"JVM Option files to write. Values: AUTO (by Cassandra version), single (jvm.options for Cassandra 3.x) or split (jvm-server.options, jvm8-server.options and jvm11-server.options for Cassandra 4.x, plus jvm17-server.options for 5.0).")
+
+	flag.StringVar(&config.JvmServerOptionsTemplate, "conf-jvm-server-options-template", config.JvmServerOptionsTemplate,
+		"Template for jvm-server.options which holds options for all JDKs. Used when conf-jvm-options-layout is split.")
+
+	flag.StringVar(&config.JvmServerOptionsFileName, "conf-jvm-server-options-file", config.JvmServerOptionsFileName,
+		"jvm-server.options location which will be overwritten with template.")
+
+	flag.StringVar(&config.Jvm8ServerOptionsTemplate, "conf-jvm8-server-options-template", config.Jvm8ServerOptionsTemplate,
+		"Template for jvm8-server.options which holds JDK 8 options. Used when conf-jvm-options-layout is split.")
+
+	flag.StringVar(&config.Jvm8ServerOptionsFileName, "conf-jvm8-server-options-file", config.Jvm8ServerOptionsFileName,
+		"jvm8-server.options location which will be overwritten with template.")
+
+	flag.StringVar(&config.Jvm11ServerOptionsTemplate, "conf-jvm11-server-options-template", config.Jvm11ServerOptionsTemplate,
+		"Template for jvm11-server.options which holds JDK 11 and later options. Used when conf-jvm-options-layout is split.")
+
+	flag.StringVar(&config.Jvm11ServerOptionsFileName, "conf-jvm11-server-options-file", config.Jvm11ServerOptionsFileName,
+		"jvm11-server.options location which will be overwritten with template.")
+
+	flag.StringVar(&config.Jvm17ServerOptionsTemplate, "conf-jvm17-server-options-template", config.Jvm17ServerOptionsTemplate,
+		"Template for jvm17-server.options which holds the JDK 17 options of Cassandra 5.0.")
+
+	flag.StringVar(&config.Jvm17ServerOptionsFileName, "conf-jvm17-server-options-file", config.Jvm17ServerOptionsFileName,
+		"jvm17-server.options location for Cassandra 5.0 which will be overwritten with template.")
+
+	flag.StringVar(&config.CassandraVersion, "cassandra-version", config.CassandraVersion,
+		"Cassandra version used to pick the embedded templates, i.e., 3.11, 4.0, 4.1 or 5.0. Defaults to the version of lib/apache-cassandra-*.jar")
+
 
 	flag.StringVar(&config.G1ParallelGCThreads, "g1-parallel-threads", config.G1ParallelGCThreads,
 		"The count of G1 Parallel threads. Values: AUTO, or some number. Uses ergonomics to pick a thread count")
@@ -433,23 +1295,68 @@ func bindCommandlineArgs(config *Config, logger lg.Logger) {
 	flag.BoolVar(&config.GCStatsEnabled, "gc_stats_enabled", config.GCStatsEnabled,
 		"Enable logging GC stats from JVM.")
 
-	flag.StringVar(&config.CmsYoungGenSize, "cms-young-gen-size", config.CmsYoungGenSize,
+	flag.Var(&config.CmsYoungGenSize, "cms-young-gen-size",
 		"If using CMS as GC, selects the proper size for the CMS YoungGen. Set this to a specific size of AUTO for environment ergonomics")
 
-	flag.StringVar(&config.MaxHeapSize, "max-heap-size", config.MaxHeapSize,
-		"Sets the MaxHeapSize using a size string, i.e., 10GB or uses AUTO to enable system environment ergonomics. (70% of free heap)")
+	flag.Var(&config.MaxHeapSize, "max-heap-size",
+		"Sets the MaxHeapSize using a size, i.e., 10GB, 10g, 10240MiB or 4859m, or uses AUTO to enable system environment ergonomics. (See heap-policy)")
+
+	flag.StringVar(&config.HeapPolicy, "heap-policy", config.HeapPolicy,
+		"Heap sizing policy used when max-heap-size is AUTO. Values: cassandra-default, percent:N, fixed or g1-large. The policies cap heaps at 31GB to keep compressed oops")
+
+	flag.Var(&config.MinHeapSize, "min-heap-size",
+		"Sets the MinHeapSize using a size, i.e., 10GB, 10g, 10240MiB or 4859m, or uses AUTO to enable system environment ergonomics. (Set to MaxHeapSize)")
 
-	flag.StringVar(&config.MinHeapSize, "min-heap-size", config.MinHeapSize,
-		"Sets the MaxHeapSize using a size string, i.e., 10GB or uses AUTO to enable system environment ergonomics. (Set to MaxHeapSize)")
+	flag.StringVar(&config.MemoryBasis, "memory-basis", config.MemoryBasis,
+		"Memory used for heap ergonomics. Values: total, available or cgroup-limit (container limit or total memory)")
+
+	flag.IntVar(&config.CpuCount, "cpu-count", config.CpuCount,
+		"Number of CPUs used for GC ergonomics. 0 detects the CPUs allowed by the cgroup CPU quota and cpuset")
+
+	flag.IntVar(&config.DataDiskCount, "data-disk-count", config.DataDiskCount,
+		"Number of data disks used for concurrency ergonomics. 0 counts the devices that hold the data directories")
+
+	flag.StringVar(&config.ConcurrentReads, "concurrent-reads", config.ConcurrentReads,
+		"Concurrent reads. Values: AUTO (16 * data disks), or some number")
+
+	flag.StringVar(&config.ConcurrentWrites, "concurrent-writes", config.ConcurrentWrites,
+		"Concurrent writes. Values: AUTO (8 * CPUs), or some number")
+
+	flag.StringVar(&config.ConcurrentCounterWrites, "concurrent-counter-writes", config.ConcurrentCounterWrites,
+		"Concurrent counter writes. Values: AUTO (16 * data disks), or some number")
+
+	flag.StringVar(&config.ConcurrentMaterializedViewWrites, "concurrent-materialized-view-writes",
+		config.ConcurrentMaterializedViewWrites,
+		"Concurrent materialized view writes. Values: AUTO (8 * CPUs), or some number")
+
+	flag.StringVar(&config.SystemRoot, "system-root", config.SystemRoot,
+		"Root of the file system used to read /proc/meminfo and /sys/fs/cgroup. Defaults to /")
 
 	flag.StringVar(&config.YamlConfigTemplate, "conf-yaml-template", config.YamlConfigTemplate,
 		"Location of cassandra configuration template")
 
+	flag.StringVar(&config.YamlMode, "conf-yaml-mode", config.YamlMode,
+		"How cassandra.yaml is written. Values: template, or patch to edit the stock cassandra.yaml")
+
+	flag.StringVar(&config.YamlSourceFileName, "conf-yaml-source-file", config.YamlSourceFileName,
+		"Location of the stock cassandra.yaml that patch mode edits")
+
+	flag.Var(&config.YamlOverrides, "yaml-override",
+		"Sets a cassandra.yaml key, i.e., -yaml-override client_encryption_options.enabled=true. Can be repeated")
+
 	dataDir := flag.String("data-dirs", "", "Location of Cassandra Data directories")
 	help := flag.Bool("help-info", false, "Prints out help information")
 
 	flag.Parse()
 	initDataDirectories(config, logger, *dataDir)
+	if *consulTags != "" {
+		logger.Debug("Command line argument -consul-tags was set", *consulTags)
+		config.ConsulTags = strings.Split(*consulTags, ",")
+	}
+	if *extraJvmOpts != "" {
+		logger.Debug("Command line argument -extra-jvm-opts was set", *extraJvmOpts)
+		config.ExtraJvmOpts = strings.Fields(*extraJvmOpts)
+	}
 	if *help {
 		printHelp(config)
 	}
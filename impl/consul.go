@@ -0,0 +1,182 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// ConsulAddress is the HTTP API of the local Consul agent.
+const ConsulAddress = "http://127.0.0.1:8500"
+
+// consulServiceEntry is the part of a /v1/health/service entry that the seeds use.
+type consulServiceEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+	} `json:"Service"`
+}
+
+// consulSeeds uses the instances of a Consul service that pass their health checks, i.e., consul:cassandra or
+// consul:cassandra@dc2 for another Consul data center. An empty service uses consul_service.
+// With consul_seed_tag only the instances with that tag are used.
+// The check of consul_register only passes once Cassandra runs, so on a new cluster no instance passes. Then every
+// registered instance is used, plus this node when it registers itself as consul_service, so the first node can start.
+func consulSeeds(config *Config, value string) ([]string, error) {
+	service, datacenter := value, ""
+	if split := strings.SplitN(value, "@", 2); len(split) == 2 {
+		service, datacenter = split[0], split[1]
+	}
+	if service == "" {
+		service = config.ConsulService
+	}
+
+	query := url.Values{"passing": {"true"}}
+	if datacenter != "" {
+		query.Set("dc", datacenter)
+	}
+	if config.ConsulSeedTag != "" {
+		query.Set("tag", config.ConsulSeedTag)
+	}
+	seeds, err := consulInstances(config, service, query)
+	if err != nil || len(seeds) > 0 {
+		return seeds, err
+	}
+
+	query.Del("passing")
+	if seeds, err = consulInstances(config, service, query); err != nil {
+		return nil, err
+	}
+	if config.ConsulRegister && service == config.ConsulService && datacenter == "" {
+		address, err := localClusterAddress(config)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, address.String())
+	}
+	return seeds, nil
+}
+
+// consulInstances returns the addresses of the instances of a Consul service.
+func consulInstances(config *Config, service string, query url.Values) ([]string, error) {
+	contents, err := consulRequest(config, http.MethodGet, "/v1/health/service/"+url.PathEscape(service)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var entries []consulServiceEntry
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		return nil, fmt.Errorf("Unable to read the instances of Consul service %s: %s", service, err)
+	}
+
+	var seeds []string
+	for _, entry := range entries {
+		// The service address is optional, Consul uses the node address when it is not set.
+		if entry.Service.Address != "" {
+			seeds = append(seeds, entry.Service.Address)
+		} else if entry.Node.Address != "" {
+			seeds = append(seeds, entry.Node.Address)
+		}
+	}
+	return seeds, nil
+}
+
+// RegisterWithConsul registers this node as an instance of consul_service when consul_register is true. The instance
+// has the data center, rack and ports in its meta data and a TCP check on the cluster port, so it only passes, and is
+// preferred as a seed by other nodes, once Cassandra is running.
+func RegisterWithConsul(config *Config, logger lg.Logger) error {
+	if !config.ConsulRegister {
+		return nil
+	}
+	address, err := localClusterAddress(config)
+	if err != nil {
+		logger.ErrorError("Unable to register with Consul", err)
+		return err
+	}
+
+	registration := map[string]interface{}{
+		"ID":      config.ConsulService + "-" + address.String(),
+		"Name":    config.ConsulService,
+		"Address": address.String(),
+		"Port":    config.ClusterPort,
+		"Tags":    config.ConsulTags,
+		"Meta":    consulMeta(config),
+		"Check": map[string]string{
+			"Name":                           "Cassandra cluster port",
+			"TCP":                            net.JoinHostPort(address.String(), strconv.Itoa(config.ClusterPort)),
+			"Interval":                       "10s",
+			"Timeout":                        "2s",
+			"DeregisterCriticalServiceAfter": "72h",
+		},
+	}
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Registering with Consul", string(body))
+	if _, err := consulRequest(config, http.MethodPut, "/v1/agent/service/register", body); err != nil {
+		logger.ErrorError("Unable to register with Consul", err)
+		return err
+	}
+	return nil
+}
+
+// consulMeta is the meta data of the service instance. A data center or rack that is not set is left out.
+func consulMeta(config *Config) map[string]string {
+	meta := map[string]string{
+		"cluster_name": config.ClusterName,
+		"cluster_port": strconv.Itoa(config.ClusterPort),
+		"client_port":  strconv.Itoa(config.ClientPort),
+		"jmx_port":     strconv.Itoa(config.JmxPort),
+	}
+	if config.Datacenter != "" {
+		meta["datacenter"] = config.Datacenter
+	}
+	if config.Rack != "" {
+		meta["rack"] = config.Rack
+	}
+	return meta
+}
+
+// consulRequest calls the HTTP API of the Consul agent with the ACL token of consul_token or CONSUL_HTTP_TOKEN.
+func consulRequest(config *Config, method string, path string, body []byte) ([]byte, error) {
+	request, err := http.NewRequest(method, strings.TrimSuffix(config.ConsulAddress, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	token := config.ConsulToken
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+	if token != "" {
+		request.Header.Set("X-Consul-Token", token)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: SeedsTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Consul %s %s returned %s: %s", method, path, response.Status, strings.TrimSpace(string(contents)))
+	}
+	return contents, nil
+}
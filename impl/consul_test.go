@@ -0,0 +1,133 @@
+package impl
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// consulServer serves /v1/health/service/cassandra from the passing and registered instances and records
+// the queries and the registration.
+type consulServer struct {
+	passing    string
+	registered string
+	queries    []string
+	register   map[string]interface{}
+	token      string
+}
+
+func (consul *consulServer) start(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		consul.token = request.Header.Get("X-Consul-Token")
+		switch {
+		case request.Method == http.MethodGet && request.URL.Path == "/v1/health/service/cassandra":
+			consul.queries = append(consul.queries, request.URL.RawQuery)
+			if request.URL.Query().Get("passing") != "" {
+				writer.Write([]byte(consul.passing))
+			} else {
+				writer.Write([]byte(consul.registered))
+			}
+		case request.Method == http.MethodPut && request.URL.Path == "/v1/agent/service/register":
+			body, _ := ioutil.ReadAll(request.Body)
+			if err := json.Unmarshal(body, &consul.register); err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConsulSeedsPassing(t *testing.T) {
+	consul := &consulServer{
+		passing: `[{"Node": {"Address": "10.0.0.1"}, "Service": {"Address": ""}},
+			{"Node": {"Address": "10.0.0.9"}, "Service": {"Address": "10.0.1.2"}}]`,
+	}
+	config := &Config{ConsulAddress: consul.start(t).URL, ConsulService: "cassandra", ConsulSeedTag: "seed",
+		ConsulToken: "secret"}
+
+	seeds, err := consulSeeds(config, "@dc2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seeds, []string{"10.0.0.1", "10.0.1.2"}) {
+		t.Errorf("expected the service address, or the node address without it, got %v", seeds)
+	}
+	if !reflect.DeepEqual(consul.queries, []string{"dc=dc2&passing=true&tag=seed"}) {
+		t.Errorf("expected one passing query in dc2 with the seed tag, got %v", consul.queries)
+	}
+	if consul.token != "secret" {
+		t.Errorf("expected the consul_token header, got %q", consul.token)
+	}
+}
+
+func TestConsulSeedsFallback(t *testing.T) {
+	consul := &consulServer{
+		passing:    `[]`,
+		registered: `[{"Node": {"Address": "10.0.0.7"}, "Service": {"Address": ""}}]`,
+	}
+	config := &Config{ConsulAddress: consul.start(t).URL, ConsulService: "cassandra", ConsulRegister: true,
+		ClusterListenAddress: "10.0.0.3"}
+
+	seeds, err := consulSeeds(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seeds, []string{"10.0.0.7", "10.0.0.3"}) {
+		t.Errorf("expected the registered instances and this node, got %v", seeds)
+	}
+	if !reflect.DeepEqual(consul.queries, []string{"passing=true", ""}) {
+		t.Errorf("expected a passing query then a query without it, got %v", consul.queries)
+	}
+
+	config.ConsulRegister = false
+	consul.registered = `[]`
+	if seeds, err := consulSeeds(config, "cassandra"); err != nil || len(seeds) != 0 {
+		t.Errorf("expected no seeds without consul_register, got %v and %v", seeds, err)
+	}
+}
+
+func TestRegisterWithConsul(t *testing.T) {
+	consul := &consulServer{}
+	config := &Config{ConsulAddress: consul.start(t).URL, ConsulService: "cassandra", ConsulRegister: true,
+		ConsulTags: []string{"seed"}, ClusterName: "prod", ClusterBroadcastAddress: "10.0.0.3",
+		ClusterPort: 7000, ClientPort: 9042, JmxPort: 7199, Datacenter: "us-east-1"}
+
+	if err := RegisterWithConsul(config, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if consul.register["ID"] != "cassandra-10.0.0.3" || consul.register["Address"] != "10.0.0.3" ||
+		consul.register["Port"] != float64(7000) {
+		t.Errorf("unexpected registration %v", consul.register)
+	}
+	expected := map[string]interface{}{"cluster_name": "prod", "cluster_port": "7000", "client_port": "9042",
+		"jmx_port": "7199", "datacenter": "us-east-1"}
+	if !reflect.DeepEqual(consul.register["Meta"], expected) {
+		t.Errorf("expected the meta data %v without the empty rack, got %v", expected, consul.register["Meta"])
+	}
+	check, _ := consul.register["Check"].(map[string]interface{})
+	if check["TCP"] != "10.0.0.3:7000" {
+		t.Errorf("expected a TCP check on the cluster port, got %v", check)
+	}
+}
+
+func TestRegisterWithConsulDisabled(t *testing.T) {
+	consul := &consulServer{}
+	config := &Config{ConsulAddress: consul.start(t).URL, ConsulService: "cassandra"}
+	if err := RegisterWithConsul(config, testLogger()); err != nil || consul.register != nil {
+		t.Errorf("expected no registration without consul_register, got %v and %v", consul.register, err)
+	}
+}
+
+func TestRegisterWithConsulError(t *testing.T) {
+	config := &Config{ConsulAddress: (&consulServer{}).start(t).URL + "/missing", ConsulService: "cassandra",
+		ConsulRegister: true, ClusterListenAddress: "10.0.0.3", ClusterPort: 7000}
+	if err := RegisterWithConsul(config, testLogger()); err == nil {
+		t.Error("expected an error when Consul refuses the registration")
+	}
+}
@@ -0,0 +1,121 @@
+package impl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// GetCPUCount returns the number of CPUs the process may use on the system found under root.
+// The count is the lowest of the online CPUs, the cpuset and the CFS quota rounded up.
+// Root is normally "/", but can point to a fixture tree.
+func GetCPUCount(root string) int {
+	count := onlineCPUs(root)
+
+	if cpus := readCgroupCpuset(root); cpus > 0 && cpus < count {
+		count = cpus
+	}
+	if cpus := readCgroupCPUQuota(root); cpus > 0 && cpus < count {
+		count = cpus
+	}
+	return count
+}
+
+// onlineCPUs uses the CPUs of the host for the real root. A fixture tree has its own
+// sys/devices/system/cpu/online so it does not depend on the host running it.
+func onlineCPUs(root string) int {
+	if filepath.Clean(root) != "/" && root != "" {
+		if cpus := readCPUList(filepath.Join(root, "sys", "devices", "system", "cpu", "online")); cpus > 0 {
+			return cpus
+		}
+	}
+	return runtime.NumCPU()
+}
+
+func readCgroupCpuset(root string) int {
+	for _, fileName := range cgroupFiles(root, "", "cpuset.cpus.effective") {
+		if cpus := readCPUList(fileName); cpus > 0 {
+			return cpus
+		}
+	}
+	for _, fileName := range cgroupFiles(root, "cpuset", "cpuset.cpus") {
+		if cpus := readCPUList(fileName); cpus > 0 {
+			return cpus
+		}
+	}
+	return 0
+}
+
+// readCgroupCPUQuota returns the CFS quota in whole CPUs, or 0 when there is no quota.
+func readCgroupCPUQuota(root string) int {
+	// cgroup v2 cpu.max holds "$QUOTA $PERIOD" where quota is "max" when unlimited.
+	for _, fileName := range cgroupFiles(root, "", "cpu.max") {
+		bytes, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			continue
+		}
+		fields := strings.Fields(string(bytes))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		return quotaToCPUs(fields[0], fields[1])
+	}
+	for _, fileName := range cgroupFiles(root, "cpu", "cpu.cfs_quota_us") {
+		quota, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			continue
+		}
+		period, err := ioutil.ReadFile(strings.TrimSuffix(fileName, "cpu.cfs_quota_us") + "cpu.cfs_period_us")
+		if err != nil {
+			return 0
+		}
+		return quotaToCPUs(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+	}
+	return 0
+}
+
+func quotaToCPUs(quotaValue string, periodValue string) int {
+	quota, err := strconv.ParseInt(quotaValue, 10, 64)
+	if err != nil || quota <= 0 {
+		return 0
+	}
+	period, err := strconv.ParseInt(periodValue, 10, 64)
+	if err != nil || period <= 0 {
+		return 0
+	}
+	return int((quota + period - 1) / period)
+}
+
+// readCPUList counts the CPUs in a cpuset list file such as "0-3,8,10-11".
+func readCPUList(fileName string) int {
+	bytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return 0
+	}
+	return countCPUList(strings.TrimSpace(string(bytes)))
+}
+
+func countCPUList(list string) int {
+	count := 0
+	for _, part := range strings.Split(list, ",") {
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		low, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return 0
+		}
+		high := low
+		if len(bounds) == 2 {
+			high, err = strconv.Atoi(bounds[1])
+			if err != nil || high < low {
+				return 0
+			}
+		}
+		count += high - low + 1
+	}
+	return count
+}
@@ -0,0 +1,84 @@
+package impl
+
+import "testing"
+
+func TestGetCPUCountOnline(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online": "0-7\n",
+	})
+	if count := GetCPUCount(root); count != 8 {
+		t.Errorf("expected 8 CPUs, got %d", count)
+	}
+}
+
+func TestGetCPUCountCgroupV1Quota(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online":                  "0-15\n",
+		"proc/self/cgroup":                               "3:cpu,cpuacct:/docker/abc\n",
+		"sys/fs/cgroup/cpu/docker/abc/cpu.cfs_quota_us":  "250000\n",
+		"sys/fs/cgroup/cpu/docker/abc/cpu.cfs_period_us": "100000\n",
+	})
+	if count := GetCPUCount(root); count != 3 {
+		t.Errorf("expected the 2.5 CPU quota to round up to 3, got %d", count)
+	}
+}
+
+func TestGetCPUCountCgroupV1Unlimited(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online":       "0-15\n",
+		"sys/fs/cgroup/cpu/cpu.cfs_quota_us":  "-1\n",
+		"sys/fs/cgroup/cpu/cpu.cfs_period_us": "100000\n",
+	})
+	if count := GetCPUCount(root); count != 16 {
+		t.Errorf("expected 16 CPUs, got %d", count)
+	}
+}
+
+func TestGetCPUCountCgroupV1Cpuset(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online":    "0-15\n",
+		"sys/fs/cgroup/cpuset/cpuset.cpus": "0-1,4,6-7\n",
+	})
+	if count := GetCPUCount(root); count != 5 {
+		t.Errorf("expected 5 CPUs, got %d", count)
+	}
+}
+
+func TestGetCPUCountCgroupV2(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online":                     "0-31\n",
+		"proc/self/cgroup":                                  "0::/kubepods/pod1\n",
+		"sys/fs/cgroup/kubepods/pod1/cpu.max":               "400000 100000\n",
+		"sys/fs/cgroup/kubepods/pod1/cpuset.cpus.effective": "0-5\n",
+	})
+	if count := GetCPUCount(root); count != 4 {
+		t.Errorf("expected the quota of 4 CPUs, got %d", count)
+	}
+}
+
+func TestGetCPUCountCgroupV2Max(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"sys/devices/system/cpu/online":       "0-31\n",
+		"sys/fs/cgroup/cpu.max":               "max 100000\n",
+		"sys/fs/cgroup/cpuset.cpus.effective": "0-11\n",
+	})
+	if count := GetCPUCount(root); count != 12 {
+		t.Errorf("expected the cpuset of 12 CPUs, got %d", count)
+	}
+}
+
+func TestCountCPUList(t *testing.T) {
+	tests := map[string]int{
+		"0":           1,
+		"0-3":         4,
+		"0-3,8,10-11": 7,
+		"":            0,
+		"3-1":         0,
+		"a-b":         0,
+	}
+	for list, expected := range tests {
+		if count := countCPUList(list); count != expected {
+			t.Errorf("%q: expected %d, got %d", list, expected, count)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// Discovery providers.
+const (
+	DiscoveryNone  = "none"
+	DiscoveryEC2   = "ec2"
+	DiscoveryGCE   = "gce"
+	DiscoveryAzure = "azure"
+	//OpenStack config drive and cloud-init NoCloud, the endpoint is a directory.
+	DiscoveryConfigDrive = "configdrive"
+	DiscoveryNoCloud     = "nocloud"
+)
+
+// How long a discovery provider waits for each metadata request.
+const DiscoveryTimeout = 2 * time.Second
+
+// InstanceMetadata is what a discovery provider learned about the instance cassandra-cloud runs on.
+// Templates can use it, i.e., {{.Instance.InstanceType}}.
+type InstanceMetadata struct {
+	Provider       string
+	InstanceID     string
+	InstanceType   string
+	Hostname       string
+	PrivateAddress string
+	PublicAddress  string
+	Region         string
+	Zone           string
+	//GCE project id.
+	Project string
+	//Instance attributes (GCE) or tags (Azure) set on the instance, i.e., {{index .Instance.Attributes "cassandra-seed"}}.
+	Attributes map[string]string
+	//Data center and rack named the way the snitch of the cloud names them.
+	Datacenter string
+	Rack       string
+	//cloud.conf fragment from the user data, merged into the config.
+	CloudConfig string
+}
+
+// DiscoveryProvider reads the instance metadata from the metadata service, or directory, at endpoint.
+// An empty endpoint uses the default endpoint of the provider.
+type DiscoveryProvider func(endpoint string, logger lg.Logger) (*InstanceMetadata, error)
+
+// DiscoveryProviders are the values of the discovery setting.
+var DiscoveryProviders = map[string]DiscoveryProvider{
+	DiscoveryEC2:         DiscoverEC2,
+	DiscoveryGCE:         DiscoverGCE,
+	DiscoveryAzure:       DiscoverAzure,
+	DiscoveryConfigDrive: DiscoverConfigDrive,
+	DiscoveryNoCloud:     DiscoverNoCloud,
+}
+
+// initDiscovery runs before the defaults are set. It asks the discovery provider about the instance and merges the
+// cloud.conf fragment of the user data, so the fragment can set anything cloud.conf can, i.e., home_dir or kubernetes.
+// The discovery setting is read from cloud.conf, the environment and the command line, which is not parsed yet.
+func initDiscovery(config *Config, logger lg.Logger) error {
+	overrideWithEnvOrDefault("CASSANDRA_DISCOVERY", &config.Discovery, DiscoveryNone, logger)
+	overrideWithEnvOrDefault("CASSANDRA_DISCOVERY_ENDPOINT", &config.DiscoveryEndpoint, "", logger)
+	if value, found := commandLineArg("discovery"); found {
+		config.Discovery = value
+	}
+	if value, found := commandLineArg("discovery-endpoint"); found {
+		config.DiscoveryEndpoint = value
+	}
+
+	config.Discovery = strings.ToLower(config.Discovery)
+	if config.Discovery == "" || config.Discovery == DiscoveryNone {
+		return nil
+	}
+	provider, ok := DiscoveryProviders[config.Discovery]
+	if !ok {
+		return fmt.Errorf("Unknown discovery provider %s", config.Discovery)
+	}
+
+	logger.Debug("Discovering the instance with", config.Discovery, config.DiscoveryEndpoint)
+	metadata, err := provider(config.DiscoveryEndpoint, logger)
+	if err != nil {
+		return fmt.Errorf("Unable to discover the instance with %s: %s", config.Discovery, err)
+	}
+	metadata.Provider = config.Discovery
+	config.Instance = *metadata
+	if metadata.CloudConfig != "" {
+		logger.Debug("Merging the cloud.conf fragment from the user data")
+		if err := mergeCloudConfig(config, metadata.CloudConfig, logger); err != nil {
+			return fmt.Errorf("Unable to merge the cloud.conf fragment of the %s user data: %s", config.Discovery, err)
+		}
+	}
+	return nil
+}
+
+// applyInstanceMetadata runs after the command line is bound and fills in the addresses, data center and rack that
+// were not set in cloud.conf, the environment or the command line. An address or an interface counts as set.
+// The broadcast address is only filled in with the discovered cluster address, a node listening on a configured
+// address or interface would otherwise tell the other nodes to connect to an address it does not listen on.
+func applyInstanceMetadata(config *Config, logger lg.Logger) {
+	metadata := config.Instance
+	if metadata.Provider == "" {
+		return
+	}
+	clusterDiscovered := false
+	if metadata.PrivateAddress != "" {
+		if config.ClusterListenAddress == "" && config.ClusterListenInterface == "" {
+			logger.Debug("Using the discovered cluster address", metadata.PrivateAddress)
+			config.ClusterListenAddress = metadata.PrivateAddress
+			clusterDiscovered = true
+		}
+		if config.ClientListenAddress == "" && config.ClientListenInterface == "" {
+			logger.Debug("Using the discovered client address", metadata.PrivateAddress)
+			config.ClientListenAddress = metadata.PrivateAddress
+		}
+	}
+	if clusterDiscovered && config.ClusterBroadcastAddress == "" {
+		// Nodes in other regions can only reach the public address, nodes in the same network use the private one.
+		if config.MultiDataCenter && metadata.PublicAddress != "" {
+			config.ClusterBroadcastAddress = metadata.PublicAddress
+		} else {
+			config.ClusterBroadcastAddress = metadata.PrivateAddress
+		}
+	}
+	if config.Datacenter == "" {
+		config.Datacenter = metadata.Datacenter
+	}
+	if config.Rack == "" {
+		config.Rack = metadata.Rack
+	}
+}
+
+// initListenAddresses runs after discovery and falls back to localhost for the addresses that are still not set.
+// Clients can't connect to 0.0.0.0, so it fails when client_address is 0.0.0.0 without a client broadcast address.
+func initListenAddresses(config *Config, logger lg.Logger) error {
+	if config.ClientListenAddress != "" && config.ClientListenInterface != "" {
+		logger.Error("The client listen address and the client listen interface can't both be set")
+	} else if config.ClientListenAddress == "" && config.ClientListenInterface == "" {
+		logger.Debug("ClientListenAddress and ClientListenInterface were not set, setting to localhost")
+		config.ClientListenAddress = "localhost"
+	}
+	if config.ClientListenAddress == "0.0.0.0" && config.ClientBroadcastAddress == "" {
+		return fmt.Errorf("The client broadcast address has to be set when the client listen address is 0.0.0.0")
+	}
+	if config.ClusterListenAddress != "" && config.ClusterListenInterface != "" {
+		logger.Error("The cluster listen address and the cluster listen interface can't both be set")
+	} else if config.ClusterListenAddress == "" && config.ClusterListenInterface == "" {
+		logger.Debug("ClusterListenAddress and ClusterListenInterface were not set, setting to localhost")
+		config.ClusterListenAddress = "localhost"
+	}
+	return nil
+}
+
+// commandLineArg finds a flag before the command line is parsed, i.e., -discovery ec2, --discovery=ec2.
+func commandLineArg(name string) (string, bool) {
+	args := os.Args[1:]
+	for index, arg := range args {
+		if arg == "--" {
+			break
+		}
+		arg = strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if arg == name && index+1 < len(args) {
+			return args[index+1], true
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return strings.TrimPrefix(arg, name+"="), true
+		}
+	}
+	return "", false
+}
+
+// metadataGet reads one value from a metadata service. A 404 means the instance does not have the value,
+// i.e., no public IP, and returns an empty string.
+func metadataGet(client *http.Client, url string, headers map[string]string) (string, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %s", url, response.Status)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,84 @@
+package impl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+func testLogger() lg.Logger {
+	return lg.NewSimpleLogger("cassandra-cloud-test")
+}
+
+// metadataServer serves the values of a metadata service below prefix, i.e., values["local-ipv4"] at
+// /latest/meta-data/local-ipv4. A key can include the query, i.e., "instance/attributes/?recursive=true".
+// A value that is not in values is a 404, like a public IP the instance does not have. authorize sees every request
+// first and answers the ones it returns false for, i.e., with a 403 when the metadata header of the cloud is missing.
+func metadataServer(t *testing.T, prefix string, values map[string]string,
+	authorize func(writer http.ResponseWriter, request *http.Request) bool) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !authorize(writer, request) {
+			return
+		}
+		path := strings.TrimPrefix(request.URL.Path, prefix)
+		value, found := values[path+"?"+request.URL.RawQuery]
+		if !found {
+			value, found = values[path]
+		}
+		if !found {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writer.Write([]byte(value))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestApplyInstanceMetadataBroadcast(t *testing.T) {
+	metadata := InstanceMetadata{Provider: DiscoveryEC2, PrivateAddress: "10.0.1.5", PublicAddress: "54.1.2.3",
+		Datacenter: "us-east-1", Rack: "us-east-1a"}
+
+	config := &Config{Instance: metadata}
+	applyInstanceMetadata(config, testLogger())
+	if config.ClusterBroadcastAddress != "10.0.1.5" {
+		t.Errorf("expected the private broadcast address, got %s", config.ClusterBroadcastAddress)
+	}
+	if config.ClusterListenAddress != "10.0.1.5" || config.ClientListenAddress != "10.0.1.5" {
+		t.Errorf("expected the private listen addresses, got %s and %s", config.ClusterListenAddress, config.ClientListenAddress)
+	}
+	if config.Datacenter != "us-east-1" || config.Rack != "us-east-1a" {
+		t.Errorf("expected the discovered data center and rack, got %s and %s", config.Datacenter, config.Rack)
+	}
+
+	config = &Config{Instance: metadata, MultiDataCenter: true}
+	applyInstanceMetadata(config, testLogger())
+	if config.ClusterBroadcastAddress != "54.1.2.3" {
+		t.Errorf("expected the public broadcast address with multi_dc, got %s", config.ClusterBroadcastAddress)
+	}
+
+	config = &Config{Instance: metadata, MultiDataCenter: true, ClusterBroadcastAddress: "10.9.9.9",
+		ClusterListenInterface: "eth1", Datacenter: "dc1"}
+	applyInstanceMetadata(config, testLogger())
+	if config.ClusterBroadcastAddress != "10.9.9.9" || config.ClusterListenAddress != "" || config.Datacenter != "dc1" {
+		t.Errorf("expected the configured values to be kept, got %s, %s and %s",
+			config.ClusterBroadcastAddress, config.ClusterListenAddress, config.Datacenter)
+	}
+
+	for _, config := range []*Config{
+		{Instance: metadata, ClusterListenAddress: "192.168.1.5"},
+		{Instance: metadata, ClusterListenInterface: "eth1", MultiDataCenter: true},
+	} {
+		applyInstanceMetadata(config, testLogger())
+		if config.ClusterBroadcastAddress != "" {
+			t.Errorf("expected no broadcast address when the cluster address is configured, got %s",
+				config.ClusterBroadcastAddress)
+		}
+		if config.ClientListenAddress != "10.0.1.5" {
+			t.Errorf("expected the discovered client address, got %s", config.ClientListenAddress)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package impl
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// CountDataDisks returns the number of distinct devices that hold the data directories.
+// Directories that do not exist yet are counted by their closest existing parent, and
+// directories that can't be checked at all count as one disk each.
+func CountDataDisks(dataDirs []string) int {
+	devices := make(map[uint64]bool)
+	unknown := 0
+	for _, dir := range dataDirs {
+		device, found := deviceOf(dir)
+		if !found {
+			unknown++
+			continue
+		}
+		devices[device] = true
+	}
+	if count := len(devices) + unknown; count > 0 {
+		return count
+	}
+	return 1
+}
+
+func deviceOf(dir string) (uint64, bool) {
+	for path := filepath.Clean(dir); ; path = filepath.Dir(path) {
+		if info, err := os.Stat(path); err == nil {
+			if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+				return uint64(stat.Dev), true
+			}
+			return 0, false
+		}
+		if path == filepath.Dir(path) {
+			return 0, false
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// Ec2MetadataEndpoint is the instance metadata service (IMDS) of EC2.
+const Ec2MetadataEndpoint = "http://169.254.169.254"
+
+// Seconds the IMDSv2 session token is valid. Discovery only needs it for a few requests.
+const ec2TokenTTL = "60"
+
+// DiscoverEC2 reads the instance metadata with IMDSv2, which needs a session token for every request.
+// The data center and rack follow the standard naming of Ec2Snitch, i.e., us-east-1 and us-east-1a.
+func DiscoverEC2(endpoint string, logger lg.Logger) (*InstanceMetadata, error) {
+	if endpoint == "" {
+		endpoint = Ec2MetadataEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	client := &http.Client{Timeout: DiscoveryTimeout}
+
+	token, err := ec2Token(client, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	metadata := &InstanceMetadata{}
+	values := []struct {
+		path     string
+		value    *string
+		required bool
+	}{
+		{"local-ipv4", &metadata.PrivateAddress, true},
+		{"public-ipv4", &metadata.PublicAddress, false},
+		{"local-hostname", &metadata.Hostname, false},
+		{"placement/region", &metadata.Region, true},
+		{"placement/availability-zone", &metadata.Zone, true},
+		{"instance-type", &metadata.InstanceType, false},
+		{"instance-id", &metadata.InstanceID, false},
+	}
+	for _, value := range values {
+		*value.value, err = metadataGet(client, endpoint+"/latest/meta-data/"+value.path, headers)
+		if err != nil {
+			return nil, err
+		}
+		if value.required && *value.value == "" {
+			return nil, fmt.Errorf("EC2 metadata has no %s", value.path)
+		}
+		logger.Debug("EC2 metadata", value.path, *value.value)
+	}
+
+	metadata.Datacenter = metadata.Region
+	metadata.Rack = metadata.Zone
+	return metadata, nil
+}
+
+// ec2Token gets an IMDSv2 session token.
+func ec2Token(client *http.Client, endpoint string) (string, error) {
+	request, err := http.NewRequest(http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", ec2TokenTTL)
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to get an IMDSv2 token, %s returned %s", endpoint, response.Status)
+	}
+	token, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -0,0 +1,89 @@
+package impl
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// ec2MetadataServer serves IMDSv2, every metadata request needs the token from PUT /latest/api/token.
+func ec2MetadataServer(t *testing.T, values map[string]string) string {
+	return metadataServer(t, "/latest/meta-data/", values, func(writer http.ResponseWriter, request *http.Request) bool {
+		if request.URL.Path == "/latest/api/token" {
+			if request.Method != http.MethodPut || request.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+				writer.WriteHeader(http.StatusBadRequest)
+				return false
+			}
+			writer.Write([]byte("test-token"))
+			return false
+		}
+		if request.Header.Get("X-aws-ec2-metadata-token") != "test-token" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}).URL
+}
+
+var testEc2Values = map[string]string{
+	"local-ipv4":                  "10.0.1.5",
+	"public-ipv4":                 "54.1.2.3",
+	"local-hostname":              "ip-10-0-1-5.ec2.internal",
+	"placement/region":            "us-east-1",
+	"placement/availability-zone": "us-east-1a",
+	"instance-type":               "i3.xlarge",
+	"instance-id":                 "i-0123456789",
+}
+
+func TestDiscoverEC2(t *testing.T) {
+	metadata, err := DiscoverEC2(ec2MetadataServer(t, testEc2Values)+"/", testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := InstanceMetadata{
+		InstanceID:     "i-0123456789",
+		InstanceType:   "i3.xlarge",
+		Hostname:       "ip-10-0-1-5.ec2.internal",
+		PrivateAddress: "10.0.1.5",
+		PublicAddress:  "54.1.2.3",
+		Region:         "us-east-1",
+		Zone:           "us-east-1a",
+		Datacenter:     "us-east-1",
+		Rack:           "us-east-1a",
+	}
+	if !reflect.DeepEqual(*metadata, expected) {
+		t.Errorf("expected %+v, got %+v", expected, *metadata)
+	}
+}
+
+func TestDiscoverEC2WithoutPublicAddress(t *testing.T) {
+	values := make(map[string]string)
+	for path, value := range testEc2Values {
+		values[path] = value
+	}
+	delete(values, "public-ipv4")
+	metadata, err := DiscoverEC2(ec2MetadataServer(t, values), testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.PublicAddress != "" {
+		t.Errorf("expected no public address, got %s", metadata.PublicAddress)
+	}
+}
+
+func TestDiscoverEC2WithoutRegion(t *testing.T) {
+	values := map[string]string{"local-ipv4": "10.0.1.5"}
+	if _, err := DiscoverEC2(ec2MetadataServer(t, values), testLogger()); err == nil {
+		t.Error("expected an error without placement/region")
+	}
+}
+
+func TestDiscoverEC2WithoutToken(t *testing.T) {
+	server := metadataServer(t, "/", nil, func(writer http.ResponseWriter, request *http.Request) bool {
+		writer.WriteHeader(http.StatusForbidden)
+		return false
+	})
+	if _, err := DiscoverEC2(server.URL, testLogger()); err == nil {
+		t.Error("expected an error when the token request fails")
+	}
+}
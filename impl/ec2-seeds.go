@@ -0,0 +1,228 @@
+package impl
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ec2APIVersion = "2016-11-15"
+
+// Ec2Tags are EC2 tag filters, i.e., cassandra-cluster=prod,cassandra-seed=true.
+type Ec2Tags map[string]string
+
+func (tags *Ec2Tags) String() string {
+	if tags == nil || *tags == nil {
+		return ""
+	}
+	var pairs []string
+	for name, value := range *tags {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value for a comma separated list of tag=value.
+func (tags *Ec2Tags) Set(value string) error {
+	if *tags == nil {
+		*tags = Ec2Tags{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		split := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return fmt.Errorf("Expected tag=value but got %s", pair)
+		}
+		(*tags)[split[0]] = split[1]
+	}
+	return nil
+}
+
+// ec2Instances is the part of the DescribeInstances response that the seeds use.
+type ec2Instances struct {
+	Reservations []struct {
+		Instances []struct {
+			PrivateIPAddress string `xml:"privateIpAddress"`
+			PublicIPAddress  string `xml:"ipAddress"`
+			AvailabilityZone string `xml:"placement>availabilityZone"`
+		} `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextToken string `xml:"nextToken"`
+}
+
+// ec2Seeds finds the running instances with the ec2_seed_tags, i.e., ec2:region, ec2:az or ec2:us-west-2.
+// region uses the instances of the region of this node and az only the ones in its availability zone. Another region
+// uses the public IPs of its instances, or the private IPs when they have none, i.e., over a VPC peering.
+func ec2Seeds(config *Config, scope string) ([]string, error) {
+	region := ec2Region(config)
+	var filters [][2]string
+	switch scope = strings.ToLower(strings.TrimSpace(scope)); scope {
+	case "", "region":
+	case "az":
+		if config.Instance.Zone == "" {
+			return nil, fmt.Errorf("ec2:az needs the availability zone from discovery = \"ec2\"")
+		}
+		filters = append(filters, [2]string{"availability-zone", config.Instance.Zone})
+	default:
+		region = scope
+	}
+	if region == "" {
+		return nil, fmt.Errorf("The EC2 region is unknown, set ec2_region or discovery = \"ec2\"")
+	}
+
+	tags := config.Ec2SeedTags
+	if len(tags) == 0 {
+		tags = Ec2Tags{"cassandra-cluster": config.ClusterName, "cassandra-seed": "true"}
+	}
+	var tagNames []string
+	for name := range tags {
+		tagNames = append(tagNames, name)
+	}
+	sort.Strings(tagNames)
+	for _, name := range tagNames {
+		filters = append(filters, [2]string{"tag:" + name, tags[name]})
+	}
+	filters = append(filters, [2]string{"instance-state-name", "running"})
+
+	credentials, err := awsCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+	usePublic := region != ec2Region(config)
+
+	var seeds []string
+	nextToken := ""
+	for {
+		instances, err := describeInstances(config, region, filters, nextToken, credentials)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range instances.Reservations {
+			for _, instance := range reservation.Instances {
+				if usePublic && instance.PublicIPAddress != "" {
+					seeds = append(seeds, instance.PublicIPAddress)
+				} else if instance.PrivateIPAddress != "" {
+					seeds = append(seeds, instance.PrivateIPAddress)
+				}
+			}
+		}
+		if instances.NextToken == "" {
+			return seeds, nil
+		}
+		nextToken = instances.NextToken
+	}
+}
+
+// ec2Region is ec2_region, the region from EC2 discovery or the AWS_REGION environment variable.
+func ec2Region(config *Config) string {
+	if config.Ec2Region != "" {
+		return config.Ec2Region
+	}
+	if config.Instance.Provider == DiscoveryEC2 && config.Instance.Region != "" {
+		return config.Instance.Region
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+func describeInstances(config *Config, region string, filters [][2]string, nextToken string,
+	credentials AwsCredentials) (*ec2Instances, error) {
+
+	endpoint := config.Ec2Endpoint
+	if endpoint == "" {
+		endpoint = "https://ec2." + region + ".amazonaws.com"
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/") + "/"
+
+	form := url.Values{"Action": {"DescribeInstances"}, "Version": {ec2APIVersion}}
+	for index, filter := range filters {
+		prefix := "Filter." + strconv.Itoa(index+1)
+		form.Set(prefix+".Name", filter[0])
+		form.Set(prefix+".Value.1", filter[1])
+	}
+	if nextToken != "" {
+		form.Set("NextToken", nextToken)
+	}
+	body := []byte(form.Encode())
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	SignV4(request, body, region, "ec2", credentials, time.Now())
+
+	client := &http.Client{Timeout: SeedsTimeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DescribeInstances returned %s: %s", response.Status, strings.TrimSpace(string(contents)))
+	}
+	instances := &ec2Instances{}
+	if err := xml.Unmarshal(contents, instances); err != nil {
+		return nil, fmt.Errorf("Unable to read the DescribeInstances response: %s", err)
+	}
+	return instances, nil
+}
+
+// awsCredentials uses the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables,
+// or the credentials of the IAM role of the instance from the instance metadata service.
+func awsCredentials(config *Config) (AwsCredentials, error) {
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return AwsCredentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	endpoint := Ec2MetadataEndpoint
+	if config.Discovery == DiscoveryEC2 && config.DiscoveryEndpoint != "" {
+		endpoint = config.DiscoveryEndpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	client := &http.Client{Timeout: DiscoveryTimeout}
+	token, err := ec2Token(client, endpoint)
+	if err != nil {
+		return AwsCredentials{}, fmt.Errorf("No AWS credentials in the environment and %s", err)
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": token}
+	credentialsPath := endpoint + "/latest/meta-data/iam/security-credentials/"
+	role, err := metadataGet(client, credentialsPath, headers)
+	if err != nil {
+		return AwsCredentials{}, err
+	}
+	if role == "" {
+		return AwsCredentials{}, fmt.Errorf("No AWS credentials in the environment and the instance has no IAM role")
+	}
+	document, err := metadataGet(client, credentialsPath+strings.SplitN(role, "\n", 2)[0], headers)
+	if err != nil {
+		return AwsCredentials{}, err
+	}
+	var roleCredentials struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string `json:"SecretAccessKey"`
+		Token           string `json:"Token"`
+	}
+	if err := json.Unmarshal([]byte(document), &roleCredentials); err != nil {
+		return AwsCredentials{}, fmt.Errorf("Unable to read the credentials of IAM role %s: %s", role, err)
+	}
+	return AwsCredentials{roleCredentials.AccessKeyID, roleCredentials.SecretAccessKey, roleCredentials.Token}, nil
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDescribeInstancesPage = `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <reservationSet>
+    <item>
+      <instancesSet>
+        <item>
+          <privateIpAddress>%s</privateIpAddress>
+          <ipAddress>%s</ipAddress>
+          <placement><availabilityZone>us-east-1a</availabilityZone></placement>
+        </item>
+      </instancesSet>
+    </item>
+  </reservationSet>
+  <nextToken>%s</nextToken>
+</DescribeInstancesResponse>`
+
+// describeInstancesServer answers DescribeInstances with two pages and records the form of every request.
+func describeInstancesServer(t *testing.T, forms *[]map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !strings.HasPrefix(request.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := request.ParseForm(); err != nil {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		form := make(map[string]string)
+		for name := range request.PostForm {
+			form[name] = request.PostForm.Get(name)
+		}
+		*forms = append(*forms, form)
+		if form["NextToken"] == "" {
+			fmt.Fprintf(writer, testDescribeInstancesPage, "10.0.1.5", "54.1.1.5", "page-2")
+		} else {
+			fmt.Fprintf(writer, testDescribeInstancesPage, "10.0.1.6", "", "")
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEc2SeedsPaging(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	var forms []map[string]string
+	config := &Config{ClusterName: "prod", Ec2Region: "us-east-1", Ec2Endpoint: describeInstancesServer(t, &forms).URL}
+
+	seeds, err := ec2Seeds(config, "region")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seeds, []string{"10.0.1.5", "10.0.1.6"}) {
+		t.Errorf("expected the private addresses of both pages, got %v", seeds)
+	}
+	if len(forms) != 2 || forms[1]["NextToken"] != "page-2" {
+		t.Fatalf("expected a second request with NextToken page-2, got %v", forms)
+	}
+	expected := map[string]string{
+		"Action":           "DescribeInstances",
+		"Version":          ec2APIVersion,
+		"Filter.1.Name":    "tag:cassandra-cluster",
+		"Filter.1.Value.1": "prod",
+		"Filter.2.Name":    "tag:cassandra-seed",
+		"Filter.2.Value.1": "true",
+		"Filter.3.Name":    "instance-state-name",
+		"Filter.3.Value.1": "running",
+	}
+	if !reflect.DeepEqual(forms[0], expected) {
+		t.Errorf("expected the request %v, got %v", expected, forms[0])
+	}
+}
+
+func TestEc2SeedsOtherRegion(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	var forms []map[string]string
+	config := &Config{Ec2Region: "us-east-1", Ec2Endpoint: describeInstancesServer(t, &forms).URL,
+		Ec2SeedTags: Ec2Tags{"role": "seed"}}
+
+	seeds, err := ec2Seeds(config, "us-west-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seeds, []string{"54.1.1.5", "10.0.1.6"}) {
+		t.Errorf("expected the public address, or the private one without it, got %v", seeds)
+	}
+	if forms[0]["Filter.1.Name"] != "tag:role" || forms[0]["Filter.1.Value.1"] != "seed" {
+		t.Errorf("expected the ec2_seed_tags filter, got %v", forms[0])
+	}
+}
+
+func TestEc2SeedsAvailabilityZone(t *testing.T) {
+	config := &Config{Ec2Region: "us-east-1"}
+	if _, err := ec2Seeds(config, "az"); err == nil {
+		t.Error("expected an error for ec2:az without EC2 discovery")
+	}
+}
+
+func TestEc2SeedsError(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusForbidden)
+		writer.Write([]byte("<Response><Errors><Error><Code>UnauthorizedOperation</Code></Error></Errors></Response>"))
+	}))
+	defer server.Close()
+	config := &Config{Ec2Region: "us-east-1", Ec2Endpoint: server.URL}
+	if _, err := ec2Seeds(config, ""); err == nil || !strings.Contains(err.Error(), "UnauthorizedOperation") {
+		t.Errorf("expected the DescribeInstances error, got %v", err)
+	}
+}
+
+func TestEc2TagsSet(t *testing.T) {
+	var tags Ec2Tags
+	if err := tags.Set("cassandra-cluster=prod, cassandra-seed=true"); err != nil {
+		t.Fatal(err)
+	}
+	if tags.String() != "cassandra-cluster=prod,cassandra-seed=true" {
+		t.Errorf("unexpected tags %s", tags.String())
+	}
+	if err := tags.Set("cassandra-seed"); err == nil {
+		t.Error("expected an error without a value")
+	}
+}
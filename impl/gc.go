@@ -0,0 +1,76 @@
+package impl
+
+import (
+	lg "github.com/advantageous/go-logback/logging"
+	"strconv"
+)
+
+const (
+	GCAuto = "AUTO"
+	GCCMS  = "CMS"
+	GCG1   = "G1"
+	GCZGC  = "ZGC"
+	//Generational ZGC, JDK 21 and later.
+	GCGenerationalZGC = "GENERATIONAL_ZGC"
+	GCShenandoah      = "SHENANDOAH"
+)
+
+// selectGC picks the collector when gc is AUTO and checks that an explicit collector exists in the JDK.
+// AUTO uses the low pause collector for heaps over gc_low_pause_threshold_gbs on JDK 15 and later,
+// otherwise G1 when memory is over gc_g1_threshold_gbs and CMS when under (G1 only once CMS is removed in JDK 14).
+func selectGC(config *Config, memSize uint64, logger lg.Logger) {
+	javaVersion := config.JavaMajorVersion
+
+	if config.GC == GCAuto {
+		actualGB := int(memSize / 1000000000)
+		heapGB := int(config.MaxHeapSize.GB())
+
+		if config.HeapPolicy == HeapPolicyG1Large {
+			config.GC = GCG1
+		} else if javaVersion >= 15 && heapGB >= config.LowPauseThresholdGBs {
+			config.GC = config.LowPauseGC
+			if config.GC == GCZGC && javaVersion >= 21 {
+				config.GC = GCGenerationalZGC
+			}
+		} else if actualGB > config.G1ThresholdGBs || javaVersion >= 14 {
+			config.GC = GCG1
+		} else {
+			config.GC = GCCMS
+		}
+		logger.Debug("GC AUTO selected", config.GC, "for JDK", javaVersion, "and heap", config.MaxHeapSize)
+	}
+
+	switch config.GC {
+	case GCCMS:
+		if javaVersion >= 14 {
+			logger.Error("CMS was removed in JDK 14, using G1 for JDK", javaVersion)
+			config.GC = GCG1
+		}
+	case GCGenerationalZGC:
+		if javaVersion < 21 {
+			logger.Error("Generational ZGC needs JDK 21 or later, using ZGC for JDK", javaVersion)
+			config.GC = GCZGC
+		}
+	case GCZGC, GCShenandoah:
+		if javaVersion < 11 {
+			logger.Error(config.GC, "needs JDK 11 or later, using G1 for JDK", javaVersion)
+			config.GC = GCG1
+		}
+	case GCG1:
+	default:
+		logger.Error("Unknown GC", config.GC, "using G1. Values: CMS, G1, ZGC, GENERATIONAL_ZGC, SHENANDOAH or AUTO")
+		config.GC = GCG1
+	}
+
+	if (config.GC == GCZGC || config.GC == GCGenerationalZGC) && !config.ZGCSoftMaxHeapSize.IsAuto() && javaVersion < 13 {
+		logger.Error("zgc_soft_max_heap_size needs JDK 13 or later, it is not set for JDK", javaVersion)
+	}
+
+	if config.ZGCConcGCThreads == "AUTO" {
+		threads := config.CpuCount / 4
+		if threads < 1 {
+			threads = 1
+		}
+		config.ZGCConcGCThreads = strconv.Itoa(threads)
+	}
+}
@@ -0,0 +1,74 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// GceMetadataEndpoint is the metadata server of Google Compute Engine.
+const GceMetadataEndpoint = "http://metadata.google.internal"
+
+// DiscoverGCE reads the metadata server of the instance, every request needs the Metadata-Flavor: Google header.
+// The data center and rack follow GoogleCloudSnitch, zone us-central1-a is data center us-central1 and rack a.
+func DiscoverGCE(endpoint string, logger lg.Logger) (*InstanceMetadata, error) {
+	if endpoint == "" {
+		endpoint = GceMetadataEndpoint
+	}
+	base := strings.TrimSuffix(endpoint, "/") + "/computeMetadata/v1/"
+	client := &http.Client{Timeout: DiscoveryTimeout}
+	headers := map[string]string{"Metadata-Flavor": "Google"}
+
+	metadata := &InstanceMetadata{}
+	var zone, machineType, attributes string
+	values := []struct {
+		path     string
+		value    *string
+		required bool
+	}{
+		{"instance/network-interfaces/0/ip", &metadata.PrivateAddress, true},
+		{"instance/network-interfaces/0/access-configs/0/external-ip", &metadata.PublicAddress, false},
+		{"instance/hostname", &metadata.Hostname, false},
+		{"instance/id", &metadata.InstanceID, false},
+		{"instance/zone", &zone, true},
+		{"instance/machine-type", &machineType, false},
+		{"project/project-id", &metadata.Project, false},
+		{"instance/attributes/?recursive=true", &attributes, false},
+	}
+	for _, value := range values {
+		var err error
+		*value.value, err = metadataGet(client, base+value.path, headers)
+		if err != nil {
+			return nil, err
+		}
+		if value.required && *value.value == "" {
+			return nil, fmt.Errorf("GCE metadata has no %s", value.path)
+		}
+		logger.Debug("GCE metadata", value.path, *value.value)
+	}
+
+	// The zone and machine type are resource names, i.e., projects/123456/zones/us-central1-a.
+	metadata.Zone = lastPathElement(zone)
+	metadata.InstanceType = lastPathElement(machineType)
+	if attributes != "" {
+		if err := json.Unmarshal([]byte(attributes), &metadata.Attributes); err != nil {
+			return nil, fmt.Errorf("Unable to read the GCE instance attributes: %s", err)
+		}
+	}
+
+	split := strings.LastIndex(metadata.Zone, "-")
+	if split <= 0 {
+		return nil, fmt.Errorf("GCE zone %s is not region-zone", metadata.Zone)
+	}
+	metadata.Region = metadata.Zone[:split]
+	metadata.Datacenter = metadata.Region
+	metadata.Rack = metadata.Zone[split+1:]
+	return metadata, nil
+}
+
+func lastPathElement(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
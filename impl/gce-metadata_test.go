@@ -0,0 +1,73 @@
+package impl
+
+import (
+	"net/http"
+	"testing"
+)
+
+// gceMetadataServer serves the GCE metadata server, which refuses requests without Metadata-Flavor: Google.
+func gceMetadataServer(t *testing.T, values map[string]string) string {
+	return metadataServer(t, "/computeMetadata/v1/", values, func(writer http.ResponseWriter, request *http.Request) bool {
+		if request.Header.Get("Metadata-Flavor") != "Google" {
+			writer.WriteHeader(http.StatusForbidden)
+			return false
+		}
+		return true
+	}).URL
+}
+
+func TestDiscoverGCE(t *testing.T) {
+	server := gceMetadataServer(t, map[string]string{
+		"instance/network-interfaces/0/ip":                           "10.128.0.7",
+		"instance/network-interfaces/0/access-configs/0/external-ip": "35.1.2.3",
+		"instance/hostname":                                          "cassandra-1.c.project.internal",
+		"instance/id":                                                "4242",
+		"instance/zone":                                              "projects/123456/zones/us-central1-a",
+		"instance/machine-type":                                      "projects/123456/machineTypes/n2-highmem-8",
+		"project/project-id":                                         "my-project",
+		"instance/attributes/?recursive=true":                        `{"cassandra-seed":"true","startup-script":"echo"}`,
+	})
+	metadata, err := DiscoverGCE(server, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.PrivateAddress != "10.128.0.7" || metadata.PublicAddress != "35.1.2.3" {
+		t.Errorf("unexpected addresses %s and %s", metadata.PrivateAddress, metadata.PublicAddress)
+	}
+	if metadata.Zone != "us-central1-a" || metadata.Region != "us-central1" {
+		t.Errorf("unexpected zone %s and region %s", metadata.Zone, metadata.Region)
+	}
+	if metadata.Datacenter != "us-central1" || metadata.Rack != "a" {
+		t.Errorf("expected data center us-central1 and rack a, got %s and %s", metadata.Datacenter, metadata.Rack)
+	}
+	if metadata.InstanceType != "n2-highmem-8" || metadata.InstanceID != "4242" || metadata.Project != "my-project" {
+		t.Errorf("unexpected instance %s, %s in %s", metadata.InstanceType, metadata.InstanceID, metadata.Project)
+	}
+	if metadata.Attributes["cassandra-seed"] != "true" {
+		t.Errorf("expected the cassandra-seed attribute, got %v", metadata.Attributes)
+	}
+}
+
+func TestDiscoverGCEWithoutExternalAddress(t *testing.T) {
+	server := gceMetadataServer(t, map[string]string{
+		"instance/network-interfaces/0/ip": "10.128.0.7",
+		"instance/zone":                    "projects/123456/zones/europe-west1-b",
+	})
+	metadata, err := DiscoverGCE(server, testLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.PublicAddress != "" || metadata.Attributes != nil {
+		t.Errorf("expected no public address and no attributes, got %s and %v", metadata.PublicAddress, metadata.Attributes)
+	}
+	if metadata.Datacenter != "europe-west1" || metadata.Rack != "b" {
+		t.Errorf("expected data center europe-west1 and rack b, got %s and %s", metadata.Datacenter, metadata.Rack)
+	}
+}
+
+func TestDiscoverGCEWithoutZone(t *testing.T) {
+	server := gceMetadataServer(t, map[string]string{"instance/network-interfaces/0/ip": "10.128.0.7"})
+	if _, err := DiscoverGCE(server, testLogger()); err == nil {
+		t.Error("expected an error without instance/zone")
+	}
+}
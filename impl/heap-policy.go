@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	//Heaps above this size lose compressed oops which wastes memory and lengthens GC pauses.
+	CompressedOopsCeiling = 31 * Gibibyte
+
+	HeapPolicyCassandraDefault = "cassandra-default"
+	HeapPolicyPercent          = "percent"
+	HeapPolicyFixed            = "fixed"
+	HeapPolicyG1Large          = "g1-large"
+)
+
+// HeapSizes are the heap and young generation sizes in bytes picked by a HeapPolicy.
+type HeapSizes struct {
+	MaxHeap  uint64
+	YoungGen uint64
+}
+
+// HeapPolicy computes heap sizes from the memory in bytes and the CPU count.
+type HeapPolicy func(memory uint64, cpuCount int) HeapSizes
+
+// HeapPolicies holds the named heap policies. percent:N and fixed are handled by LookupHeapPolicy.
+var HeapPolicies = map[string]HeapPolicy{
+	HeapPolicyCassandraDefault: cassandraDefaultHeapPolicy,
+	HeapPolicyG1Large:          g1LargeHeapPolicy,
+}
+
+// LookupHeapPolicy finds a heap policy by name. The name can be cassandra-default, g1-large or percent:N.
+// The fixed policy has no function since the sizes come from max_heap_size and cms_young_gen_size.
+func LookupHeapPolicy(name string) (HeapPolicy, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if policy, found := HeapPolicies[name]; found {
+		return policy, nil
+	}
+	if strings.HasPrefix(name, HeapPolicyPercent+":") {
+		percent, err := strconv.Atoi(strings.TrimPrefix(name, HeapPolicyPercent+":"))
+		if err != nil || percent <= 0 || percent > 100 {
+			return nil, fmt.Errorf("Heap policy %s needs a percent between 1 and 100", name)
+		}
+		return percentHeapPolicy(uint64(percent)), nil
+	}
+	return nil, fmt.Errorf("Unknown heap policy %s, expected cassandra-default, percent:N, fixed or g1-large", name)
+}
+
+// Cassandra's calculate_heap_sizes from cassandra-env.sh: max(min(1/2 ram, 1GB), min(1/4 ram, 8GB)).
+func cassandraDefaultHeapPolicy(memory uint64, cpuCount int) HeapSizes {
+	maxHeap := maxUint64(minUint64(memory/2, 1*Gibibyte), minUint64(memory/4, 8*Gibibyte))
+	return HeapSizes{MaxHeap: maxHeap, YoungGen: youngGenSize(maxHeap, cpuCount)}
+}
+
+func percentHeapPolicy(percent uint64) HeapPolicy {
+	return func(memory uint64, cpuCount int) HeapSizes {
+		maxHeap := capHeapSize(memory / 100 * percent)
+		return HeapSizes{MaxHeap: maxHeap, YoungGen: youngGenSize(maxHeap, cpuCount)}
+	}
+}
+
+// Half of the memory for large G1 heaps, capped below the compressed oops ceiling.
+func g1LargeHeapPolicy(memory uint64, cpuCount int) HeapSizes {
+	maxHeap := capHeapSize(memory / 2)
+	return HeapSizes{MaxHeap: maxHeap, YoungGen: youngGenSize(maxHeap, cpuCount)}
+}
+
+// Cassandra's young gen formula: min(100MB * cores, 1/4 heap).
+func youngGenSize(maxHeap uint64, cpuCount int) uint64 {
+	if cpuCount < 1 {
+		cpuCount = 1
+	}
+	return minUint64(uint64(cpuCount)*100*Mebibyte, maxHeap/4)
+}
+
+func capHeapSize(heapSize uint64) uint64 {
+	return minUint64(heapSize, CompressedOopsCeiling)
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,62 @@
+package impl
+
+import "testing"
+
+func TestHeapErgonomics(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		maxHeap  Size
+		youngGen Size
+		memory   uint64
+		cpus     int
+		expected heapConfig
+	}{
+		{"default small", HeapPolicyCassandraDefault, AutoSize, AutoSize, 1 * Gibibyte, 2, heapConfig{"512m", "128m"}},
+		{"default quarter", HeapPolicyCassandraDefault, AutoSize, AutoSize, 16 * Gibibyte, 4, heapConfig{"4g", "400m"}},
+		{"default 8g limit", HeapPolicyCassandraDefault, AutoSize, AutoSize, 64 * Gibibyte, 16, heapConfig{"8g", "1600m"}},
+		{"default no cpus", HeapPolicyCassandraDefault, AutoSize, AutoSize, 16 * Gibibyte, 0, heapConfig{"4g", "100m"}},
+		{"percent", "percent:50", AutoSize, AutoSize, 16 * Gibibyte, 2, heapConfig{"8191m", "200m"}},
+		{"percent capped", "percent:75", AutoSize, AutoSize, 128 * Gibibyte, 8, heapConfig{"31g", "800m"}},
+		{"invalid percent", "percent:0", AutoSize, AutoSize, 16 * Gibibyte, 4, heapConfig{"4g", "400m"}},
+		{"unknown policy", "huge", AutoSize, AutoSize, 16 * Gibibyte, 4, heapConfig{"4g", "400m"}},
+		{"g1-large", HeapPolicyG1Large, AutoSize, AutoSize, 32 * Gibibyte, 64, heapConfig{"16g", "4g"}},
+		{"g1-large capped", HeapPolicyG1Large, AutoSize, AutoSize, 256 * Gibibyte, 32, heapConfig{"31g", "3200m"}},
+		{"fixed", HeapPolicyFixed, "12g", AutoSize, 64 * Gibibyte, 8, heapConfig{"12g", "800m"}},
+		{"fixed without a size", HeapPolicyFixed, AutoSize, AutoSize, 16 * Gibibyte, 4, heapConfig{"4g", "400m"}},
+		{"explicit over the cap", HeapPolicyCassandraDefault, "40g", AutoSize, 64 * Gibibyte, 2, heapConfig{"40g", "200m"}},
+		{"explicit young gen", HeapPolicyCassandraDefault, AutoSize, "256m", 16 * Gibibyte, 4, heapConfig{"4g", "256m"}},
+	}
+	for _, test := range tests {
+		config := &Config{HeapPolicy: test.policy, MaxHeapSize: test.maxHeap, CmsYoungGenSize: test.youngGen, CpuCount: test.cpus}
+		heapErgonomics(config, test.memory, testLogger())
+		if actual := (heapConfig{config.MaxHeapSize, config.CmsYoungGenSize}); actual != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestYoungGenSize(t *testing.T) {
+	tests := []struct {
+		maxHeap  uint64
+		cpus     int
+		expected uint64
+	}{
+		{8 * Gibibyte, 4, 400 * Mebibyte},
+		{8 * Gibibyte, 32, 2 * Gibibyte},
+		{1 * Gibibyte, 4, 256 * Mebibyte},
+		{8 * Gibibyte, -1, 100 * Mebibyte},
+	}
+	for _, test := range tests {
+		if actual := youngGenSize(test.maxHeap, test.cpus); actual != test.expected {
+			t.Errorf("%s and %d CPUs: expected %s, got %s", SizeOf(test.maxHeap), test.cpus, SizeOf(test.expected),
+				SizeOf(actual))
+		}
+	}
+}
+
+// heapConfig are the heap sizes heapErgonomics leaves in the config.
+type heapConfig struct {
+	MaxHeapSize     Size
+	CmsYoungGenSize Size
+}
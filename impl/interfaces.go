@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// IP versions for interface_ip_preference.
+const (
+	IPPreferenceIPv4 = "ipv4"
+	IPPreferenceIPv6 = "ipv6"
+)
+
+// initInterfaces looks up the address of cluster_interface and client_interface so a missing interface fails here
+// and not when Cassandra starts. The cluster interface address is the default broadcast address. With
+// resolve_interfaces the interfaces are replaced by their address, which also works for interfaces with several addresses.
+func initInterfaces(config *Config, logger lg.Logger) error {
+	config.InterfaceIPPreference = strings.ToLower(config.InterfaceIPPreference)
+	if config.InterfaceIPPreference != IPPreferenceIPv4 && config.InterfaceIPPreference != IPPreferenceIPv6 {
+		return fmt.Errorf("Interface IP preference %s is not ipv4 or ipv6", config.InterfaceIPPreference)
+	}
+
+	if config.ClusterListenInterface != "" {
+		address, err := PreferredInterfaceAddress(config.ClusterListenInterface, config.InterfaceIPPreference)
+		if err != nil {
+			return fmt.Errorf("Unable to use the cluster interface: %s", err)
+		}
+		logger.Debug("Cluster interface", config.ClusterListenInterface, "has address", address)
+		if config.ClusterBroadcastAddress == "" {
+			config.ClusterBroadcastAddress = address
+		}
+		if config.ResolveInterfaces {
+			config.ClusterListenAddress = address
+			config.ClusterListenInterface = ""
+		}
+	}
+
+	if config.ClientListenInterface != "" {
+		address, err := PreferredInterfaceAddress(config.ClientListenInterface, config.InterfaceIPPreference)
+		if err != nil {
+			return fmt.Errorf("Unable to use the client interface: %s", err)
+		}
+		logger.Debug("Client interface", config.ClientListenInterface, "has address", address)
+		if config.ResolveInterfaces {
+			config.ClientListenAddress = address
+			config.ClientListenInterface = ""
+		}
+	}
+	return nil
+}
+
+// InterfaceAddress returns the first IPv4 address of the network interface, or its first IPv6 address if it has no IPv4 address.
+func InterfaceAddress(interfaceName string) (string, error) {
+	return PreferredInterfaceAddress(interfaceName, IPPreferenceIPv4)
+}
+
+// PreferredInterfaceAddress returns the first address of the preferred IP version, ipv4 or ipv6, and falls back to
+// the other version. Link local addresses are skipped since other nodes can't use them.
+func PreferredInterfaceAddress(interfaceName string, preference string) (string, error) {
+	addresses, err := interfaceAddrs(interfaceName)
+	if err != nil {
+		return "", err
+	}
+	address := preferredAddress(addresses, preference)
+	if address == "" {
+		return "", fmt.Errorf("Network interface %s has no IP address", interfaceName)
+	}
+	return address, nil
+}
+
+// interfaceAddrs reads the addresses of a network interface, tests replace it.
+var interfaceAddrs = func(interfaceName string) ([]net.Addr, error) {
+	networkInterface, err := net.InterfaceByName(interfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("Network interface %s does not exist", interfaceName)
+	}
+	addresses, err := networkInterface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the addresses of network interface %s: %s", interfaceName, err)
+	}
+	return addresses, nil
+}
+
+// preferredAddress picks the address of PreferredInterfaceAddress, an empty string if there is none.
+func preferredAddress(addresses []net.Addr, preference string) string {
+	var ipv4, ipv6 string
+	for _, address := range addresses {
+		ipNet, ok := address.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = ipNet.IP.String()
+			}
+		} else if ipv6 == "" {
+			ipv6 = ipNet.IP.String()
+		}
+	}
+	if preference == IPPreferenceIPv6 && ipv6 != "" {
+		return ipv6
+	}
+	if ipv4 != "" {
+		return ipv4
+	}
+	return ipv6
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+// testAddrs parses CIDR addresses the way net.Interface.Addrs returns them.
+func testAddrs(t *testing.T, cidrs ...string) []net.Addr {
+	t.Helper()
+	var addresses []net.Addr
+	for _, cidr := range cidrs {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ipNet.IP = ip
+		addresses = append(addresses, ipNet)
+	}
+	return addresses
+}
+
+func TestPreferredAddress(t *testing.T) {
+	tests := []struct {
+		addresses  []string
+		preference string
+		expected   string
+	}{
+		{[]string{"10.0.0.5/24", "10.0.0.6/24"}, IPPreferenceIPv4, "10.0.0.5"},
+		{[]string{"fd00::5/64", "10.0.0.5/24"}, IPPreferenceIPv4, "10.0.0.5"},
+		{[]string{"10.0.0.5/24", "fd00::5/64"}, IPPreferenceIPv6, "fd00::5"},
+		{[]string{"10.0.0.5/24"}, IPPreferenceIPv6, "10.0.0.5"},
+		{[]string{"fd00::5/64"}, IPPreferenceIPv4, "fd00::5"},
+		{[]string{"fe80::1/64", "169.254.1.1/16", "10.0.0.5/24"}, IPPreferenceIPv6, "10.0.0.5"},
+		{[]string{"169.254.1.1/16", "fe80::1/64", "fd00::5/64"}, IPPreferenceIPv4, "fd00::5"},
+		{[]string{"fe80::1/64", "169.254.1.1/16"}, IPPreferenceIPv4, ""},
+		{nil, IPPreferenceIPv4, ""},
+	}
+	for _, test := range tests {
+		if address := preferredAddress(testAddrs(t, test.addresses...), test.preference); address != test.expected {
+			t.Errorf("%v with %s: expected %q, got %q", test.addresses, test.preference, test.expected, address)
+		}
+	}
+	unix := []net.Addr{&net.UnixAddr{Name: "/tmp/socket"}}
+	if address := preferredAddress(append(unix, testAddrs(t, "10.0.0.5/24")...), IPPreferenceIPv4); address != "10.0.0.5" {
+		t.Errorf("expected addresses that are not IP networks to be skipped, got %q", address)
+	}
+}
+
+func TestInitInterfaces(t *testing.T) {
+	defer func(addrs func(string) ([]net.Addr, error)) { interfaceAddrs = addrs }(interfaceAddrs)
+	interfaceAddrs = func(interfaceName string) ([]net.Addr, error) {
+		switch interfaceName {
+		case "eth0":
+			return testAddrs(t, "fe80::1/64", "10.0.0.5/24", "fd00::5/64"), nil
+		case "eth1":
+			return testAddrs(t, "192.168.1.5/24"), nil
+		case "link-local":
+			return testAddrs(t, "fe80::2/64"), nil
+		}
+		return nil, fmt.Errorf("Network interface %s does not exist", interfaceName)
+	}
+
+	config := &Config{ClusterListenInterface: "eth0", ClientListenInterface: "eth1", InterfaceIPPreference: "IPv6"}
+	if err := initInterfaces(config, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if config.ClusterBroadcastAddress != "fd00::5" || config.ClusterListenInterface != "eth0" ||
+		config.ClusterListenAddress != "" || config.ClientListenInterface != "eth1" {
+		t.Errorf("expected the interfaces to be kept and the IPv6 broadcast address, got %+v", config)
+	}
+
+	config = &Config{ClusterListenInterface: "eth0", ClientListenInterface: "eth1", ClusterBroadcastAddress: "54.1.2.3",
+		InterfaceIPPreference: IPPreferenceIPv4, ResolveInterfaces: true}
+	if err := initInterfaces(config, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if config.ClusterListenAddress != "10.0.0.5" || config.ClusterListenInterface != "" ||
+		config.ClientListenAddress != "192.168.1.5" || config.ClientListenInterface != "" ||
+		config.ClusterBroadcastAddress != "54.1.2.3" {
+		t.Errorf("expected resolve_interfaces to replace the interfaces with their address, got %+v", config)
+	}
+
+	for _, config := range []*Config{
+		{ClusterListenInterface: "eth9", InterfaceIPPreference: IPPreferenceIPv4},
+		{ClientListenInterface: "link-local", InterfaceIPPreference: IPPreferenceIPv4},
+		{ClusterListenInterface: "eth0", InterfaceIPPreference: "ipv5"},
+	} {
+		if err := initInterfaces(config, testLogger()); err == nil {
+			t.Errorf("expected an error for %+v", config)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FindJavaHome returns the first JDK home with a release file. The java_home setting is tried first,
+// then JAVA_HOME, then the jdk, jre and java directories under the Cassandra home.
+func FindJavaHome(javaHome string, cassandraHome string) (string, error) {
+	candidates := []string{javaHome, os.Getenv("JAVA_HOME")}
+	if cassandraHome != "" {
+		candidates = append(candidates,
+			filepath.Join(cassandraHome, "jdk"),
+			filepath.Join(cassandraHome, "jre"),
+			filepath.Join(cassandraHome, "java"))
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(candidate, "release")); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("Unable to find a JDK with a release file, set java_home or JAVA_HOME")
+}
+
+// ReadJavaMajorVersion reads JAVA_VERSION from the release file of a JDK home.
+func ReadJavaMajorVersion(javaHome string) (int, error) {
+	bytes, err := ioutil.ReadFile(filepath.Join(javaHome, "release"))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		if !strings.HasPrefix(line, "JAVA_VERSION=") {
+			continue
+		}
+		return ParseJavaMajorVersion(strings.Trim(strings.TrimPrefix(line, "JAVA_VERSION="), "\" \r"))
+	}
+	return 0, fmt.Errorf("JAVA_VERSION not found in %s/release", javaHome)
+}
+
+// ParseJavaMajorVersion turns a Java version such as 1.8.0_292, 11.0.2 or 17 into its major version.
+func ParseJavaMajorVersion(version string) (int, error) {
+	// Drop suffixes such as -ea or +35.
+	if end := strings.IndexFunc(version, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != '_' }); end != -1 {
+		version = version[:end]
+	}
+	parts := strings.Split(version, ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Java version %q", version)
+	}
+	if major == 1 && len(parts) > 1 {
+		// Java 8 and earlier report themselves as 1.x.
+		major, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return 0, fmt.Errorf("Invalid Java version %q", version)
+		}
+	}
+	return major, nil
+}
@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseJavaMajorVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected int
+		valid    bool
+	}{
+		{"1.8.0_292", 8, true},
+		{"1.7.0", 7, true},
+		{"11.0.2", 11, true},
+		{"17", 17, true},
+		{"21-ea", 21, true},
+		{"17.0.1+12", 17, true},
+		{"junk", 0, false},
+		{"", 0, false},
+		{"1.x", 0, false},
+	}
+	for _, test := range tests {
+		major, err := ParseJavaMajorVersion(test.version)
+		if (err == nil) != test.valid || major != test.expected {
+			t.Errorf("%q: expected %d (valid %v), got %d and %v", test.version, test.expected, test.valid, major, err)
+		}
+	}
+}
+
+func TestReadJavaMajorVersion(t *testing.T) {
+	javaHome := t.TempDir()
+	release := "IMPLEMENTOR=\"Eclipse Adoptium\"\nJAVA_VERSION=\"17.0.9\"\n"
+	if err := ioutil.WriteFile(filepath.Join(javaHome, "release"), []byte(release), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if version, err := ReadJavaMajorVersion(javaHome); err != nil || version != 17 {
+		t.Errorf("expected 17, got %d and %v", version, err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(javaHome, "release"), []byte("IMPLEMENTOR=x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadJavaMajorVersion(javaHome); err == nil {
+		t.Error("expected an error without JAVA_VERSION")
+	}
+}
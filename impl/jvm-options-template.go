@@ -62,11 +62,11 @@ const JvmOptionsTemplate = `
 # a lower priority to avoid interfering with client workload
 -XX:+UseThreadPriorities
 
-# allows lowering thread priority without being root on linux - probably
+{{if le .JavaMajorVersion 8}}# allows lowering thread priority without being root on linux - probably
 # not necessary on Windows but doesn't harm anything.
 # see http://tech.stolsvik.com/2010/01/linux-java-thread-priorities-workar
 -XX:ThreadPriorityPolicy=42
-
+{{end}}
 # Enable heap-dump if there's an OOM
 -XX:+HeapDumpOnOutOfMemoryError
 
@@ -81,9 +81,9 @@ const JvmOptionsTemplate = `
 # transparent hugepage allocation more effective.
 -XX:+AlwaysPreTouch
 
-# Disable biased locking as it does not benefit Cassandra.
+{{if lt .JavaMajorVersion 15}}# Disable biased locking as it does not benefit Cassandra.
 -XX:-UseBiasedLocking
-
+{{end}}
 # Enable thread-local allocation blocks and allow the JVM to automatically
 # resize them at runtime.
 -XX:+UseTLAB
@@ -97,6 +97,24 @@ const JvmOptionsTemplate = `
 # comment out this entry to enable IPv6 support).
 -Djava.net.preferIPv4Stack=true
 
+{{if ge .JavaMajorVersion 11}}# Needed by Cassandra for JMX and off heap memory on JDK 11 and later.
+-Djdk.attach.allowAttachSelf=true
+--add-exports java.base/jdk.internal.misc=ALL-UNNAMED
+--add-exports java.base/jdk.internal.ref=ALL-UNNAMED
+--add-exports java.base/sun.nio.ch=ALL-UNNAMED
+--add-exports java.management.rmi/com.sun.jmx.remote.internal.rmi=ALL-UNNAMED
+--add-exports java.rmi/sun.rmi.registry=ALL-UNNAMED
+--add-exports java.rmi/sun.rmi.server=ALL-UNNAMED
+--add-exports java.sql/java.sql=ALL-UNNAMED
+--add-opens java.base/java.lang.module=ALL-UNNAMED
+--add-opens java.base/jdk.internal.loader=ALL-UNNAMED
+--add-opens java.base/jdk.internal.ref=ALL-UNNAMED
+--add-opens java.base/jdk.internal.reflect=ALL-UNNAMED
+--add-opens java.base/jdk.internal.math=ALL-UNNAMED
+--add-opens java.base/jdk.internal.module=ALL-UNNAMED
+--add-opens java.base/jdk.internal.util.jar=ALL-UNNAMED
+--add-opens jdk.management/com.sun.management.internal=ALL-UNNAMED
+{{end}}
 ### Debug options
 
 # uncomment to enable flight recorder
@@ -157,8 +175,8 @@ const JvmOptionsTemplate = `
 # times. If in doubt, and if you do not particularly want to tweak, go
 # 100 MB per physical CPU core.
 -Xmn{{.CmsYoungGenSize}}
--XX:+UseParNewGC
--XX:+UseConcMarkSweepGC
+{{if le .JavaMajorVersion 8}}-XX:+UseParNewGC
+{{end}}-XX:+UseConcMarkSweepGC
 -XX:+CMSParallelRemarkEnabled
 -XX:SurvivorRatio=8
 -XX:MaxTenuringThreshold=1
@@ -196,10 +214,34 @@ const JvmOptionsTemplate = `
 -XX:ConcGCThreads={{.G1ConcGCThreads}}
 {{end}}
 
+{{if or (eq .GC "ZGC") (eq .GC "GENERATIONAL_ZGC")}}
+## Use the Z garbage collector. Pauses stay under a few milliseconds regardless of heap size.
+## ZGC is experimental before JDK 15.
+{{if lt .JavaMajorVersion 15}}-XX:+UnlockExperimentalVMOptions
+{{end}}-XX:+UseZGC
+{{if eq .GC "GENERATIONAL_ZGC"}}## Generational ZGC collects young objects more often (JDK 21+).
+-XX:+ZGenerational
+{{end}}
+# ZGC has no compressed oops and sizes its own generations, so -Xmn is not set.
+-XX:ConcGCThreads={{.ZGCConcGCThreads}}
+{{if and (not .ZGCSoftMaxHeapSize.IsAuto) (ge .JavaMajorVersion 13)}}# ZGC tries to keep the heap under this size and only grows past it to avoid stalls (JDK 13+).
+-XX:SoftMaxHeapSize={{.ZGCSoftMaxHeapSize}}
+{{end}}
+{{end}}
+
+{{if eq .GC "SHENANDOAH"}}
+## Use the Shenandoah garbage collector which compacts concurrently with the application.
+## Shenandoah is experimental before JDK 15.
+{{if lt .JavaMajorVersion 15}}-XX:+UnlockExperimentalVMOptions
+{{end}}-XX:+UseShenandoahGC
+# adaptive is the default, compact returns memory eagerly, static and aggressive are for testing.
+-XX:ShenandoahGCHeuristics={{.ShenandoahHeuristics}}
+{{end}}
+
 
 
 ### GC logging options -- uncomment to enable
-{{if .GCStatsEnabled}}# Turn on GC stats
+{{if .GCStatsEnabled}}{{if le .JavaMajorVersion 8}}# Turn on GC stats
 -XX:+PrintGCDetails
 -XX:+PrintGCDateStamps
 -XX:+PrintHeapAtGC
@@ -207,10 +249,12 @@ const JvmOptionsTemplate = `
 -XX:+PrintGCApplicationStoppedTime
 -XX:+PrintPromotionFailure
 #-XX:PrintFLSStatistics=1
--Xloggc:{{.CassandraHome}}/log/gc.log
+-Xloggc:{{.LogDir}}/gc.log
 -XX:+UseGCLogFileRotation
 -XX:NumberOfGCLogFiles=10
 -XX:GCLogFileSize=10M
-{{end}}
+{{else}}# Turn on GC stats with unified logging, the Print GC flags were removed in JDK 9.
+-Xlog:gc=info,heap*=trace,age*=debug,safepoint=info,promotion*=trace:file={{.LogDir}}/gc.log:time,uptime,pid,tid,level:filecount=10,filesize=10485760
+{{end}}{{end}}
 
 `
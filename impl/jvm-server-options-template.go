@@ -0,0 +1,234 @@
+package impl
+
+import (
+	"os"
+	"io/ioutil"
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// initJvmServerOptionsTemplates creates the templates for the split jvm options files used by Cassandra 4.x.
+func initJvmServerOptionsTemplates(config *Config, logger lg.Logger) {
+	templates := map[string]string{
+		config.JvmServerOptionsTemplate:   JvmServerOptionsTemplate,
+		config.Jvm8ServerOptionsTemplate:  Jvm8ServerOptionsTemplate,
+		config.Jvm11ServerOptionsTemplate: Jvm11ServerOptionsTemplate,
+		config.Jvm17ServerOptionsTemplate: Jvm17ServerOptionsTemplate,
+	}
+	for templateFileName, contents := range templates {
+		if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
+			logger.Debug("Cassandra JVM server option template does not exist so we are creating it", templateFileName)
+			err = ioutil.WriteFile(templateFileName, []byte(contents), 0644)
+			if err != nil {
+				logger.ErrorError("Unable to write template file "+templateFileName, err)
+			}
+		}
+	}
+}
+
+// JvmServerOptionsTemplate holds the options for every JDK (Cassandra 4.x jvm-server.options).
+const JvmServerOptionsTemplate = `
+# This file was generated with the template {{.JvmServerOptionsTemplate}} by cassandra-cloud.
+# Options for all JDKs. JDK specific options are in jvm8-server.options and jvm11-server.options.
+
+{{if .ReplaceAddress}}# Replacing address
+-Dcassandra.replace_address={{.ReplaceAddress}}
+{{end}}
+
+########################
+# GENERAL JVM SETTINGS #
+########################
+
+# enable thread priorities, primarily so we can give periodic tasks
+# a lower priority to avoid interfering with client workload
+-XX:+UseThreadPriorities
+
+# Enable heap-dump if there's an OOM
+-XX:+HeapDumpOnOutOfMemoryError
+
+# Per-thread stack size.
+-Xss256k
+
+# Larger interned string table, for gossip's benefit (CASSANDRA-6410)
+-XX:StringTableSize=1000003
+
+# Make sure all memory is faulted and zeroed on startup.
+# This helps prevent soft faults in containers and makes
+# transparent hugepage allocation more effective.
+-XX:+AlwaysPreTouch
+
+# Enable thread-local allocation blocks and allow the JVM to automatically
+# resize them at runtime.
+-XX:+UseTLAB
+-XX:+ResizeTLAB
+
+# http://www.evanjones.ca/jvm-mmap-pause.html
+-XX:+PerfDisableSharedMem
+
+# Prefer binding to IPv4 network intefaces (when net.ipv6.bindv6only=1).
+-Djava.net.preferIPv4Stack=true
+
+#################
+# HEAP SETTINGS #
+#################
+
+-Xms{{.MinHeapSize}}
+-Xmx{{.MaxHeapSize}}
+`
+
+// Jvm8ServerOptionsTemplate holds the JDK 8 options (Cassandra 4.x jvm8-server.options).
+// JDK 8 has no ZGC or Shenandoah so those fall back to G1.
+const Jvm8ServerOptionsTemplate = `
+# This file was generated with the template {{.Jvm8ServerOptionsTemplate}} by cassandra-cloud.
+# Options for JDK 8.
+
+# allows lowering thread priority without being root on linux
+-XX:ThreadPriorityPolicy=42
+
+# Disable biased locking as it does not benefit Cassandra.
+-XX:-UseBiasedLocking
+
+#################
+#  GC SETTINGS  #
+#################
+
+{{if eq .GC "CMS"}}
+### CMS Settings
+-Xmn{{.CmsYoungGenSize}}
+-XX:+UseParNewGC
+-XX:+UseConcMarkSweepGC
+-XX:+CMSParallelRemarkEnabled
+-XX:SurvivorRatio=8
+-XX:MaxTenuringThreshold=1
+-XX:CMSInitiatingOccupancyFraction=75
+-XX:+UseCMSInitiatingOccupancyOnly
+-XX:CMSWaitDuration=10000
+-XX:+CMSParallelInitialMarkEnabled
+-XX:+CMSEdenChunksRecordAlways
+# some JVMs will fill up their heap when accessed via JMX, see CASSANDRA-6541
+-XX:+CMSClassUnloadingEnabled
+{{else}}
+## Use the Hotspot garbage-first collector.
+-XX:+UseG1GC
+-XX:G1RSetUpdatingPauseTimePercent=5
+-XX:MaxGCPauseMillis=500
+-XX:InitiatingHeapOccupancyPercent=70
+-XX:ParallelGCThreads={{.G1ParallelGCThreads}}
+-XX:ConcGCThreads={{.G1ConcGCThreads}}
+{{end}}
+
+{{if .GCStatsEnabled}}# Turn on GC stats
+-XX:+PrintGCDetails
+-XX:+PrintGCDateStamps
+-XX:+PrintHeapAtGC
+-XX:+PrintTenuringDistribution
+-XX:+PrintGCApplicationStoppedTime
+-XX:+PrintPromotionFailure
+-Xloggc:{{.LogDir}}/gc.log
+-XX:+UseGCLogFileRotation
+-XX:NumberOfGCLogFiles=10
+-XX:GCLogFileSize=10M
+{{end}}
+`
+
+// Jvm11ServerOptionsTemplate holds the JDK 11 and later options (Cassandra 4.x jvm11-server.options).
+// Cassandra 4.x also reads it on JDK 17, so the JDK 17 options are added when the detected JDK is 17 or later.
+const Jvm11ServerOptionsTemplate = `
+# This file was generated with the template {{.Jvm11ServerOptionsTemplate}} by cassandra-cloud.
+# Options for JDK 11 and later.
+
+` + jvm11PlusServerOptions + `{{if ge .JavaMajorVersion 17}}
+` + jvm17Options + `{{end}}
+`
+
+// Jvm17ServerOptionsTemplate holds the JDK 17 options (Cassandra 5.0 jvm17-server.options). Cassandra 5.0 only reads
+// it on JDK 17, so the JDK 17 options do not depend on the detected JDK.
+const Jvm17ServerOptionsTemplate = `
+# This file was generated with the template {{.Jvm17ServerOptionsTemplate}} by cassandra-cloud.
+# Options for JDK 17 and later.
+
+` + jvm11PlusServerOptions + jvm17Options
+
+// jvm11PlusServerOptions are the GC settings and module options shared by the JDK 11 and JDK 17 files.
+const jvm11PlusServerOptions = `#################
+#  GC SETTINGS  #
+#################
+
+{{if eq .GC "CMS"}}
+### CMS Settings, CMS is deprecated in JDK 11 and removed in JDK 14.
+-Xmn{{.CmsYoungGenSize}}
+-XX:+UseConcMarkSweepGC
+-XX:+CMSParallelRemarkEnabled
+-XX:SurvivorRatio=8
+-XX:MaxTenuringThreshold=1
+-XX:CMSInitiatingOccupancyFraction=75
+-XX:+UseCMSInitiatingOccupancyOnly
+-XX:CMSWaitDuration=10000
+-XX:+CMSParallelInitialMarkEnabled
+-XX:+CMSEdenChunksRecordAlways
+-XX:+CMSClassUnloadingEnabled
+{{end}}
+
+{{if eq .GC "G1"}}
+## Use the Hotspot garbage-first collector.
+-XX:+UseG1GC
+-XX:G1RSetUpdatingPauseTimePercent=5
+-XX:MaxGCPauseMillis=500
+-XX:InitiatingHeapOccupancyPercent=70
+-XX:ParallelGCThreads={{.G1ParallelGCThreads}}
+-XX:ConcGCThreads={{.G1ConcGCThreads}}
+{{end}}
+
+{{if or (eq .GC "ZGC") (eq .GC "GENERATIONAL_ZGC")}}
+## Use the Z garbage collector, experimental before JDK 15.
+{{if lt .JavaMajorVersion 15}}-XX:+UnlockExperimentalVMOptions
+{{end}}-XX:+UseZGC
+{{if eq .GC "GENERATIONAL_ZGC"}}-XX:+ZGenerational
+{{end}}-XX:ConcGCThreads={{.ZGCConcGCThreads}}
+{{if and (not .ZGCSoftMaxHeapSize.IsAuto) (ge .JavaMajorVersion 13)}}-XX:SoftMaxHeapSize={{.ZGCSoftMaxHeapSize}}
+{{end}}
+{{end}}
+
+{{if eq .GC "SHENANDOAH"}}
+## Use the Shenandoah garbage collector, experimental before JDK 15.
+{{if lt .JavaMajorVersion 15}}-XX:+UnlockExperimentalVMOptions
+{{end}}-XX:+UseShenandoahGC
+-XX:ShenandoahGCHeuristics={{.ShenandoahHeuristics}}
+{{end}}
+
+{{if .GCStatsEnabled}}# Turn on GC stats with unified logging
+-Xlog:gc=info,heap*=trace,age*=debug,safepoint=info,promotion*=trace:file={{.LogDir}}/gc.log:time,uptime,pid,tid,level:filecount=10,filesize=10485760
+{{end}}
+
+# Needed by Cassandra for JMX and off heap memory on JDK 11 and later.
+-Djdk.attach.allowAttachSelf=true
+--add-exports java.base/jdk.internal.misc=ALL-UNNAMED
+--add-exports java.base/jdk.internal.ref=ALL-UNNAMED
+--add-exports java.base/sun.nio.ch=ALL-UNNAMED
+--add-exports java.management.rmi/com.sun.jmx.remote.internal.rmi=ALL-UNNAMED
+--add-exports java.rmi/sun.rmi.registry=ALL-UNNAMED
+--add-exports java.rmi/sun.rmi.server=ALL-UNNAMED
+--add-exports java.sql/java.sql=ALL-UNNAMED
+--add-opens java.base/java.lang.module=ALL-UNNAMED
+--add-opens java.base/jdk.internal.loader=ALL-UNNAMED
+--add-opens java.base/jdk.internal.ref=ALL-UNNAMED
+--add-opens java.base/jdk.internal.reflect=ALL-UNNAMED
+--add-opens java.base/jdk.internal.math=ALL-UNNAMED
+--add-opens java.base/jdk.internal.module=ALL-UNNAMED
+--add-opens java.base/jdk.internal.util.jar=ALL-UNNAMED
+--add-opens jdk.management/com.sun.management.internal=ALL-UNNAMED
+`
+
+// jvm17Options open the packages Cassandra needs under the strong encapsulation of JDK 17.
+const jvm17Options = `# JDK 17 enforces strong encapsulation.
+--add-opens java.base/sun.nio.ch=ALL-UNNAMED
+--add-opens java.base/java.io=ALL-UNNAMED
+--add-opens java.base/java.nio=ALL-UNNAMED
+--add-opens java.base/java.lang=ALL-UNNAMED
+--add-opens java.base/java.lang.reflect=ALL-UNNAMED
+--add-opens java.base/java.math=ALL-UNNAMED
+--add-opens java.base/java.net=ALL-UNNAMED
+--add-opens java.base/java.util=ALL-UNNAMED
+--add-opens java.base/java.util.concurrent=ALL-UNNAMED
+--add-opens java.base/java.util.concurrent.atomic=ALL-UNNAMED
+`
+
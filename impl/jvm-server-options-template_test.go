@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// renderTemplate renders an embedded template the way ProcessTemplate does.
+func renderTemplate(t *testing.T, text string, config *Config) string {
+	t.Helper()
+	theTemplate, err := template.New("test").Funcs(TemplateFuncs(config)).Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := theTemplate.Execute(&output, config); err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+func TestZGCSoftMaxHeapSizeNeedsJDK13(t *testing.T) {
+	tests := []struct {
+		javaVersion int
+		expected    bool
+	}{
+		{11, false},
+		{13, true},
+		{17, true},
+	}
+	for _, test := range tests {
+		config := &Config{GC: GCZGC, ZGCConcGCThreads: "2", ZGCSoftMaxHeapSize: "4g", JavaMajorVersion: test.javaVersion}
+		for name, text := range map[string]string{"jvm11-server.options": Jvm11ServerOptionsTemplate, "jvm.options": JvmOptionsTemplate} {
+			output := renderTemplate(t, text, config)
+			if found := strings.Contains(output, "-XX:SoftMaxHeapSize=4g"); found != test.expected {
+				t.Errorf("%s for JDK %d: expected SoftMaxHeapSize %v, got %v", name, test.javaVersion, test.expected, found)
+			}
+		}
+	}
+}
+
+func TestJvm17ServerOptionsAlwaysOpenJavaLang(t *testing.T) {
+	for _, javaVersion := range []int{8, 11, 17} {
+		config := &Config{GC: GCG1, G1ParallelGCThreads: "4", G1ConcGCThreads: "4", JavaMajorVersion: javaVersion}
+		if output := renderTemplate(t, Jvm17ServerOptionsTemplate, config); !strings.Contains(output, "--add-opens java.base/java.lang=ALL-UNNAMED") {
+			t.Errorf("jvm17-server.options for detected JDK %d is missing the JDK 17 opens", javaVersion)
+		}
+		output := renderTemplate(t, Jvm11ServerOptionsTemplate, config)
+		if found := strings.Contains(output, "--add-opens java.base/java.lang=ALL-UNNAMED"); found != (javaVersion >= 17) {
+			t.Errorf("jvm11-server.options for JDK %d: expected the JDK 17 opens %v, got %v", javaVersion, javaVersion >= 17, found)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// Downward API environment variables of the pod, i.e., env: [{name: POD_IP, valueFrom: {fieldRef: {fieldPath: status.podIP}}}].
+const (
+	PodIPEnv        = "POD_IP"
+	PodNameEnv      = "POD_NAME"
+	PodNamespaceEnv = "POD_NAMESPACE"
+)
+
+// Namespace file of the service account that Kubernetes mounts in every pod.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesPod is the pod cassandra-cloud runs in, i.e., {{.Pod.Ordinal}}.
+type KubernetesPod struct {
+	Name      string
+	Namespace string
+	IP        string
+	//StatefulSet and ordinal come from the pod name, cassandra-2 is ordinal 2 of cassandra.
+	StatefulSet string
+	Ordinal     int
+}
+
+// initKubernetesProfile runs first in initDefaults when kubernetes is true in cloud.conf or CASSANDRA_KUBERNETES is set.
+// It fills in the settings that neither cloud.conf nor their environment variable set, so the environment and
+// the command line still win:
+//   - cluster_address and cluster_broadcast_address are the pod IP,
+//   - client_address is 0.0.0.0 and client_broadcast_address is the pod IP,
+//   - cluster_seeds are the first pods of the StatefulSet, <sts>-0.<svc>,<sts>-1.<svc>.
+func initKubernetesProfile(config *Config, logger lg.Logger) error {
+	overrideWithEnvOrDefault("CASSANDRA_KUBERNETES_POD_INFO_DIR", &config.KubernetesPodInfoDir, "/etc/podinfo", logger)
+	overrideWithEnvOrDefault("CASSANDRA_KUBERNETES_SERVICE", &config.KubernetesService, "", logger)
+	overrideWithEnvOrDefault("CASSANDRA_KUBERNETES_CLUSTER_DOMAIN", &config.KubernetesClusterDomain, "", logger)
+	overrideNumberWithEnvOrDefault("CASSANDRA_KUBERNETES_SEED_COUNT", &config.KubernetesSeedCount, 2, logger)
+
+	pod, err := readKubernetesPod(config.KubernetesPodInfoDir)
+	if err != nil {
+		return err
+	}
+	config.Pod = *pod
+	logger.Debug("Kubernetes pod", pod.Name, "namespace", pod.Namespace, "ip", pod.IP, "ordinal", pod.Ordinal)
+
+	if config.KubernetesService == "" {
+		config.KubernetesService = pod.StatefulSet
+	}
+	var seeds []string
+	for ordinal := 0; ordinal < config.KubernetesSeedCount; ordinal++ {
+		seed := fmt.Sprintf("%s-%d.%s", pod.StatefulSet, ordinal, config.KubernetesService)
+		if config.KubernetesClusterDomain != "" {
+			seed += "." + pod.Namespace + ".svc." + config.KubernetesClusterDomain
+		}
+		seeds = append(seeds, seed)
+	}
+
+	if config.ClusterListenInterface == "" && os.Getenv("CASSANDRA_CLUSTER_INTERFACE") == "" {
+		kubernetesDefault("CASSANDRA_CLUSTER_ADDRESS", &config.ClusterListenAddress, pod.IP, logger)
+	}
+	kubernetesDefault("CASSANDRA_CLUSTER_BROADCAST_ADDRESS", &config.ClusterBroadcastAddress, pod.IP, logger)
+	if config.ClientListenInterface == "" && os.Getenv("CASSANDRA_CLIENT_INTERFACE") == "" {
+		kubernetesDefault("CASSANDRA_CLIENT_ADDRESS", &config.ClientListenAddress, "0.0.0.0", logger)
+	}
+	kubernetesDefault("CASSANDRA_CLIENT_BROADCAST_ADDRESS", &config.ClientBroadcastAddress, pod.IP, logger)
+	kubernetesDefault("CASSANDRA_CLUSTER_SEEDS", &config.ClusterSeeds, strings.Join(seeds, ","), logger)
+	return nil
+}
+
+func kubernetesDefault(envName string, value *string, profileValue string, logger lg.Logger) {
+	if *value == "" && os.Getenv(envName) == "" {
+		logger.Debug("Kubernetes profile sets", envName, "to", profileValue)
+		*value = profileValue
+	}
+}
+
+// podHostname reads the host name the pod name falls back to, tests replace it.
+var podHostname = os.Hostname
+
+// readKubernetesPod reads the pod from the downward API environment variables, then from the files of a downward API
+// volume in podInfoDir (name and namespace). The pod name falls back to the host name, which Kubernetes sets to
+// the pod name, the namespace to the service account and the IP to the address of the host name.
+func readKubernetesPod(podInfoDir string) (*KubernetesPod, error) {
+	pod := &KubernetesPod{
+		Name:      podInfo(PodNameEnv, filepath.Join(podInfoDir, "name")),
+		Namespace: podInfo(PodNamespaceEnv, filepath.Join(podInfoDir, "namespace"), serviceAccountNamespaceFile),
+		IP:        os.Getenv(PodIPEnv),
+	}
+	if pod.Name == "" {
+		hostname, err := podHostname()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the pod name: %s", err)
+		}
+		pod.Name = hostname
+	}
+	if pod.IP == "" {
+		addresses, err := net.LookupHost(pod.Name)
+		if err != nil || len(addresses) == 0 {
+			return nil, fmt.Errorf("%s is not set and the pod name %s does not resolve", PodIPEnv, pod.Name)
+		}
+		pod.IP = addresses[0]
+	}
+
+	split := strings.LastIndex(pod.Name, "-")
+	ordinal, err := strconv.Atoi(pod.Name[split+1:])
+	if split <= 0 || err != nil || ordinal < 0 {
+		return nil, fmt.Errorf("Pod %s is not part of a StatefulSet, expected <statefulset>-<ordinal>", pod.Name)
+	}
+	pod.StatefulSet = pod.Name[:split]
+	pod.Ordinal = ordinal
+	return pod, nil
+}
+
+// podInfo returns the environment variable or the contents of the first file that exists.
+func podInfo(envName string, fileNames ...string) string {
+	if value := os.Getenv(envName); value != "" {
+		return value
+	}
+	for _, fileName := range fileNames {
+		if contents, err := ioutil.ReadFile(fileName); err == nil {
+			return strings.TrimSpace(string(contents))
+		}
+	}
+	return ""
+}
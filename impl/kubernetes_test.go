@@ -0,0 +1,127 @@
+package impl
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// setPodEnv sets the downward API variables, an empty value unsets it for the test.
+func setPodEnv(t *testing.T, name, namespace, ip string) {
+	t.Setenv(PodNameEnv, name)
+	t.Setenv(PodNamespaceEnv, namespace)
+	t.Setenv(PodIPEnv, ip)
+}
+
+func TestReadKubernetesPodName(t *testing.T) {
+	tests := []struct {
+		name        string
+		statefulSet string
+		ordinal     int
+		valid       bool
+	}{
+		{"cassandra-0", "cassandra", 0, true},
+		{"cassandra-2", "cassandra", 2, true},
+		{"cassandra-dc1-rack-a-12", "cassandra-dc1-rack-a", 12, true},
+		{"cassandra", "", 0, false},
+		{"cassandra-x", "", 0, false},
+		{"cassandra-", "", 0, false},
+		{"-1", "", 0, false},
+		{"cassandra-6d9f8b7c4-x2lqp", "", 0, false},
+	}
+	for _, test := range tests {
+		setPodEnv(t, test.name, "db", "10.244.1.5")
+		pod, err := readKubernetesPod(t.TempDir())
+		if (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.name, test.valid, err)
+			continue
+		}
+		if test.valid && (pod.StatefulSet != test.statefulSet || pod.Ordinal != test.ordinal) {
+			t.Errorf("%s: expected %s and %d, got %s and %d", test.name, test.statefulSet, test.ordinal,
+				pod.StatefulSet, pod.Ordinal)
+		}
+	}
+}
+
+func TestReadKubernetesPodInfo(t *testing.T) {
+	podInfoDir := writeFixture(t, map[string]string{"name": "cassandra-1\n", "namespace": "db\n"})
+	setPodEnv(t, "", "", "10.244.1.6")
+	pod, err := readKubernetesPod(podInfoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := KubernetesPod{Name: "cassandra-1", Namespace: "db", IP: "10.244.1.6", StatefulSet: "cassandra", Ordinal: 1}
+	if *pod != expected {
+		t.Errorf("expected the pod from the downward API volume %+v, got %+v", expected, *pod)
+	}
+
+	setPodEnv(t, "cassandra-3", "prod", "10.244.1.7")
+	if pod, err = readKubernetesPod(podInfoDir); err != nil || pod.Name != "cassandra-3" || pod.Namespace != "prod" {
+		t.Errorf("expected the environment to win over the downward API volume, got %+v and %v", pod, err)
+	}
+}
+
+func TestReadKubernetesPodHostname(t *testing.T) {
+	defer func(hostname func() (string, error)) { podHostname = hostname }(podHostname)
+	setPodEnv(t, "", "db", "10.244.1.8")
+
+	podHostname = func() (string, error) { return "cassandra-4", nil }
+	pod, err := readKubernetesPod(t.TempDir())
+	if err != nil || pod.Name != "cassandra-4" || pod.Ordinal != 4 {
+		t.Errorf("expected the pod name from the host name, got %+v and %v", pod, err)
+	}
+
+	podHostname = func() (string, error) { return "", fmt.Errorf("no host name") }
+	if _, err := readKubernetesPod(t.TempDir()); err == nil {
+		t.Error("expected an error when there is no pod name and no host name")
+	}
+}
+
+func TestInitKubernetesProfile(t *testing.T) {
+	for _, name := range []string{"CASSANDRA_KUBERNETES_POD_INFO_DIR", "CASSANDRA_KUBERNETES_SERVICE",
+		"CASSANDRA_KUBERNETES_CLUSTER_DOMAIN", "CASSANDRA_KUBERNETES_SEED_COUNT", "CASSANDRA_CLUSTER_INTERFACE",
+		"CASSANDRA_CLIENT_INTERFACE", "CASSANDRA_CLUSTER_ADDRESS", "CASSANDRA_CLUSTER_BROADCAST_ADDRESS",
+		"CASSANDRA_CLIENT_ADDRESS", "CASSANDRA_CLIENT_BROADCAST_ADDRESS", "CASSANDRA_CLUSTER_SEEDS"} {
+		t.Setenv(name, "")
+	}
+	setPodEnv(t, "cassandra-2", "db", "10.244.1.5")
+	podInfoDir := filepath.Join(t.TempDir(), "podinfo")
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{"default", Config{}, "cassandra-0.cassandra,cassandra-1.cassandra"},
+		{"service", Config{KubernetesService: "cassandra-headless", KubernetesSeedCount: 3},
+			"cassandra-0.cassandra-headless,cassandra-1.cassandra-headless,cassandra-2.cassandra-headless"},
+		{"cluster domain", Config{KubernetesClusterDomain: "cluster.local", KubernetesSeedCount: 1},
+			"cassandra-0.cassandra.db.svc.cluster.local"},
+		{"configured seeds", Config{ClusterSeeds: "10.0.0.1"}, "10.0.0.1"},
+	}
+	for _, test := range tests {
+		config := test.config
+		config.KubernetesPodInfoDir = podInfoDir
+		if err := initKubernetesProfile(&config, testLogger()); err != nil {
+			t.Fatal(err)
+		}
+		if config.ClusterSeeds != test.expected {
+			t.Errorf("%s: expected the seeds %s, got %s", test.name, test.expected, config.ClusterSeeds)
+		}
+		if config.ClusterListenAddress != "10.244.1.5" || config.ClusterBroadcastAddress != "10.244.1.5" ||
+			config.ClientListenAddress != "0.0.0.0" || config.ClientBroadcastAddress != "10.244.1.5" {
+			t.Errorf("%s: expected the pod addresses, got %s, %s, %s and %s", test.name, config.ClusterListenAddress,
+				config.ClusterBroadcastAddress, config.ClientListenAddress, config.ClientBroadcastAddress)
+		}
+	}
+
+	t.Setenv("CASSANDRA_CLUSTER_SEEDS", "10.0.0.2")
+	config := Config{KubernetesPodInfoDir: podInfoDir, ClusterListenInterface: "eth0"}
+	if err := initKubernetesProfile(&config, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if config.ClusterSeeds != "" || config.ClusterListenAddress != "" {
+		t.Errorf("expected the environment and the interface to be left to the config, got %s and %s",
+			config.ClusterSeeds, config.ClusterListenAddress)
+	}
+}
@@ -0,0 +1,178 @@
+package impl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// Log formats for the logback appenders.
+const (
+	LogFormatPattern = "pattern"
+	LogFormatJSON    = "json"
+)
+
+var logLevels = map[string]bool{"ALL": true, "TRACE": true, "DEBUG": true, "INFO": true, "WARN": true, "ERROR": true, "OFF": true}
+
+// LogLevels maps logger names to levels, i.e., org.apache.cassandra.db=DEBUG.
+type LogLevels map[string]string
+
+func (levels *LogLevels) String() string {
+	if levels == nil || *levels == nil {
+		return ""
+	}
+	var pairs []string
+	for name, level := range *levels {
+		pairs = append(pairs, name+"="+level)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value for a comma separated list of logger=LEVEL.
+func (levels *LogLevels) Set(value string) error {
+	if *levels == nil {
+		*levels = LogLevels{}
+	}
+	for _, pair := range strings.Split(value, ",") {
+		split := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(split) != 2 || split[0] == "" {
+			return fmt.Errorf("Expected logger=LEVEL but got %s", pair)
+		}
+		(*levels)[split[0]] = split[1]
+	}
+	return nil
+}
+
+// initLogging validates the levels and format. org.apache.cassandra logs at DEBUG, like the stock
+// logback.xml, so debug.log is written unless log_levels sets it.
+func initLogging(config *Config, logger lg.Logger) error {
+	config.LogLevel = validLogLevel("root", config.LogLevel, logger)
+	if config.LogLevels == nil {
+		config.LogLevels = LogLevels{}
+	}
+	if _, ok := config.LogLevels["org.apache.cassandra"]; !ok {
+		config.LogLevels["org.apache.cassandra"] = "DEBUG"
+	}
+	for name, level := range config.LogLevels {
+		config.LogLevels[name] = validLogLevel(name, level, logger)
+	}
+
+	config.LogFormat = strings.ToLower(config.LogFormat)
+	if config.LogFormat != LogFormatPattern && config.LogFormat != LogFormatJSON {
+		logger.Errorf("Log format %s is not pattern or json, using pattern\n", config.LogFormat)
+		config.LogFormat = LogFormatPattern
+	}
+
+	if err := logSize("log_max_file_size", &config.LogMaxFileSize); err != nil {
+		return err
+	}
+	return logSize("log_total_size_cap", &config.LogTotalSizeCap)
+}
+
+// logSize checks a logback size. logback.xml gets whole MB, so AUTO and sizes under 1MB are rejected.
+func logSize(name string, value *Size) error {
+	if err := normalizeSize(name, value); err != nil {
+		return err
+	}
+	if value.IsAuto() || value.MB() == 0 {
+		return fmt.Errorf("Invalid %s %s, expected a size of 1MB or more", name, *value)
+	}
+	return nil
+}
+
+func validLogLevel(name string, level string, logger lg.Logger) string {
+	level = strings.ToUpper(level)
+	if !logLevels[level] {
+		logger.Errorf("Log level %s of %s is not valid, using INFO\n", level, name)
+		return "INFO"
+	}
+	return level
+}
+
+func initLogbackTemplate(templateFileName string, logger lg.Logger) {
+	if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
+		logger.Debug("Cassandra logback template does not exist so we are creating it", templateFileName)
+		err = ioutil.WriteFile(templateFileName, []byte(LogbackTemplate), 0644)
+		if err != nil {
+			logger.ErrorError("Unable to write template file "+templateFileName, err)
+		}
+	}
+}
+
+// LogbackTemplate follows the stock logback.xml: system.log at INFO, debug.log with everything and
+// the console at INFO. Sizes are written in MB since logback does not read JVM style sizes.
+const LogbackTemplate = `<!--
+This file was generated with the template {{.LogbackTemplate}} by cassandra-cloud.
+-->
+{{define "encoder"}}{{if eq .LogFormat "json"}}<encoder class="{{.LogJsonEncoder}}"/>{{else}}<encoder>
+      <pattern>%-5level [%thread] %date{ISO8601} %F:%L - %msg%n</pattern>
+    </encoder>{{end}}{{end}}
+<configuration scan="true" scanPeriod="60 seconds">
+  <jmxConfigurator />
+
+  <!-- Lets the async appender flush when the JVM shuts down. -->
+  <shutdownHook class="ch.qos.logback.core.hook.DelayingShutdownHook"/>
+
+  <!-- SYSTEMLOG rolling file appender to system.log (INFO level) -->
+
+  <appender name="SYSTEMLOG" class="ch.qos.logback.core.rolling.RollingFileAppender">
+    <filter class="ch.qos.logback.classic.filter.ThresholdFilter">
+      <level>INFO</level>
+    </filter>
+    <file>{{.LogDir}}/system.log</file>
+    <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+      <!-- rollover daily -->
+      <fileNamePattern>{{.LogDir}}/system.log.%d{yyyy-MM-dd}.%i.zip</fileNamePattern>
+      <!-- each file should be at most {{.LogMaxFileSize.MB}}MB, keep {{.LogMaxHistory}} days worth of history, but at most {{.LogTotalSizeCap.MB}}MB -->
+      <maxFileSize>{{.LogMaxFileSize.MB}}MB</maxFileSize>
+      <maxHistory>{{.LogMaxHistory}}</maxHistory>
+      <totalSizeCap>{{.LogTotalSizeCap.MB}}MB</totalSizeCap>
+    </rollingPolicy>
+    {{template "encoder" .}}
+  </appender>
+
+  <!-- DEBUGLOG rolling file appender to debug.log (all levels) -->
+
+  <appender name="DEBUGLOG" class="ch.qos.logback.core.rolling.RollingFileAppender">
+    <file>{{.LogDir}}/debug.log</file>
+    <rollingPolicy class="ch.qos.logback.core.rolling.SizeAndTimeBasedRollingPolicy">
+      <fileNamePattern>{{.LogDir}}/debug.log.%d{yyyy-MM-dd}.%i.zip</fileNamePattern>
+      <maxFileSize>{{.LogMaxFileSize.MB}}MB</maxFileSize>
+      <maxHistory>{{.LogMaxHistory}}</maxHistory>
+      <totalSizeCap>{{.LogTotalSizeCap.MB}}MB</totalSizeCap>
+    </rollingPolicy>
+    {{template "encoder" .}}
+  </appender>
+
+  <!-- ASYNCLOG assynchronous appender to debug.log (all levels) -->
+
+  <appender name="ASYNCDEBUGLOG" class="ch.qos.logback.classic.AsyncAppender">
+    <queueSize>1024</queueSize>
+    <discardingThreshold>0</discardingThreshold>
+    <includeCallerData>true</includeCallerData>
+    <appender-ref ref="DEBUGLOG" />
+  </appender>
+
+  <!-- STDOUT console appender to stdout (INFO level) -->
+
+  <appender name="STDOUT" class="ch.qos.logback.core.ConsoleAppender">
+    <filter class="ch.qos.logback.classic.filter.ThresholdFilter">
+      <level>INFO</level>
+    </filter>
+    {{template "encoder" .}}
+  </appender>
+
+  <root level="{{.LogLevel}}">
+    <appender-ref ref="SYSTEMLOG" />
+    <appender-ref ref="STDOUT" />
+    <appender-ref ref="ASYNCDEBUGLOG" />
+  </root>
+{{range $name, $level := .LogLevels}}
+  <logger name="{{$name}}" level="{{$level}}"/>{{end}}
+</configuration>
+`
@@ -0,0 +1,39 @@
+package impl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInitLoggingSizes(t *testing.T) {
+	tests := []struct {
+		maxFileSize Size
+		totalCap    Size
+		valid       bool
+	}{
+		{"50MB", "5GB", true},
+		{"1m", "1024k", true},
+		{"512k", "5GB", false},
+		{"50MB", "AUTO", false},
+		{"AUTO", "5GB", false},
+		{"0", "5GB", false},
+		{"50 MB", "fifty", false},
+	}
+	for _, test := range tests {
+		config := &Config{LogMaxFileSize: test.maxFileSize, LogTotalSizeCap: test.totalCap, LogFormat: LogFormatPattern, LogLevel: "INFO"}
+		if err := initLogging(config, testLogger()); (err == nil) != test.valid {
+			t.Errorf("%s and %s: expected valid %v, got %v", test.maxFileSize, test.totalCap, test.valid, err)
+		}
+	}
+}
+
+func TestLogbackSizesInMB(t *testing.T) {
+	config := &Config{LogMaxFileSize: "50MB", LogTotalSizeCap: "5g", LogFormat: LogFormatPattern, LogLevel: "INFO", LogDir: "/var/log/cassandra"}
+	if err := initLogging(config, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	output := renderTemplate(t, LogbackTemplate, config)
+	if !strings.Contains(output, "<maxFileSize>50MB</maxFileSize>") || !strings.Contains(output, "<totalSizeCap>5120MB</totalSizeCap>") {
+		t.Errorf("expected the sizes in MB, got %s", output)
+	}
+}
@@ -0,0 +1,182 @@
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	//Use MemTotal from /proc/meminfo.
+	MemoryBasisTotal = "total"
+	//Use MemAvailable from /proc/meminfo.
+	MemoryBasisAvailable = "available"
+	//Use the cgroup memory limit, or MemTotal if the process is not limited.
+	MemoryBasisCgroupLimit = "cgroup-limit"
+)
+
+// MemoryInfo holds the memory figures of the host or container, in bytes.
+type MemoryInfo struct {
+	Total     uint64
+	Available uint64
+	// CgroupLimit is zero when no cgroup limit applies.
+	CgroupLimit uint64
+}
+
+// ReadMemoryInfo reads /proc/meminfo and the cgroup v1/v2 memory limit relative to root.
+// Root is normally "/", but can point to a fixture tree.
+func ReadMemoryInfo(root string) (*MemoryInfo, error) {
+	info := &MemoryInfo{}
+
+	file, err := os.Open(filepath.Join(root, "proc", "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		// Values in /proc/meminfo are reported in KiB.
+		if len(fields) > 2 && fields[2] == "kB" {
+			value = value * 1024
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	info.Total = values["MemTotal"]
+	if info.Total == 0 {
+		return nil, fmt.Errorf("MemTotal not found in %s", file.Name())
+	}
+	if available, found := values["MemAvailable"]; found {
+		info.Available = available
+	} else {
+		// Kernels older than 3.14 do not report MemAvailable.
+		info.Available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+
+	info.CgroupLimit = readCgroupMemoryLimit(root)
+	if info.CgroupLimit >= info.Total {
+		// The v1 "unlimited" value is a huge number, and any limit above the host RAM is not a real limit.
+		info.CgroupLimit = 0
+	}
+	return info, nil
+}
+
+// Memory returns the memory size in bytes for the basis: total, available or cgroup-limit.
+func (info *MemoryInfo) Memory(basis string) (uint64, error) {
+	switch strings.ToLower(basis) {
+	case MemoryBasisTotal:
+		return info.Total, nil
+	case MemoryBasisAvailable:
+		if info.CgroupLimit != 0 && info.CgroupLimit < info.Available {
+			return info.CgroupLimit, nil
+		}
+		return info.Available, nil
+	case MemoryBasisCgroupLimit, "":
+		if info.CgroupLimit != 0 {
+			return info.CgroupLimit, nil
+		}
+		return info.Total, nil
+	default:
+		return 0, fmt.Errorf("Unknown memory basis %s, expected total, available or cgroup-limit", basis)
+	}
+}
+
+// GetMemory returns the memory size in bytes of the system found under root using basis.
+func GetMemory(root string, basis string) (uint64, error) {
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		return 0, err
+	}
+	return info.Memory(basis)
+}
+
+func readCgroupMemoryLimit(root string) uint64 {
+	// cgroup v2 uses memory.max which holds "max" when unlimited.
+	for _, fileName := range cgroupFiles(root, "", "memory.max") {
+		if limit, found := readCgroupNumber(fileName); found {
+			return limit
+		}
+	}
+	for _, fileName := range cgroupFiles(root, "memory", "memory.limit_in_bytes") {
+		if limit, found := readCgroupNumber(fileName); found {
+			return limit
+		}
+	}
+	return 0
+}
+
+// cgroupFiles lists the candidate locations of a cgroup control file. The process's own cgroup path from
+// /proc/self/cgroup is tried first, then the mount root which is what containers see with cgroup namespaces.
+// An empty controller means cgroup v2 (unified hierarchy).
+func cgroupFiles(root string, controller string, name string) []string {
+	mount := filepath.Join(root, "sys", "fs", "cgroup")
+	if controller != "" {
+		mount = filepath.Join(mount, controller)
+	}
+
+	var files []string
+	if cgroupPath := readCgroupPath(root, controller); cgroupPath != "" && cgroupPath != "/" {
+		files = append(files, filepath.Join(mount, cgroupPath, name))
+	}
+	return append(files, filepath.Join(mount, name))
+}
+
+// readCgroupPath finds the cgroup path for controller in /proc/self/cgroup.
+// Lines look like "4:memory:/docker/abc" for v1 and "0::/user.slice" for v2.
+func readCgroupPath(root string, controller string) string {
+	bytes, err := ioutil.ReadFile(filepath.Join(root, "proc", "self", "cgroup"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(bytes), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if controller == "" {
+			if parts[0] == "0" && parts[1] == "" {
+				return parts[2]
+			}
+			continue
+		}
+		for _, name := range strings.Split(parts[1], ",") {
+			if name == controller {
+				return parts[2]
+			}
+		}
+	}
+	return ""
+}
+
+func readCgroupNumber(fileName string) (uint64, bool) {
+	bytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(bytes))
+	if value == "max" || value == "-1" {
+		return 0, true
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
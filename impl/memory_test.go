@@ -0,0 +1,137 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testMeminfo = `MemTotal:       16384000 kB
+MemFree:         1024000 kB
+MemAvailable:    8192000 kB
+Buffers:          100000 kB
+Cached:          2000000 kB
+`
+
+// writeFixture creates a system root under a temporary directory from paths relative to root.
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, contents := range files {
+		fileName := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fileName, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestReadMemoryInfoCgroupV1(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo":     testMeminfo,
+		"proc/self/cgroup": "11:cpu,cpuacct:/docker/abc\n4:memory:/docker/abc\n",
+		"sys/fs/cgroup/memory/docker/abc/memory.limit_in_bytes": "4294967296\n",
+	})
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Total != 16384000*1024 || info.Available != 8192000*1024 || info.CgroupLimit != 4294967296 {
+		t.Errorf("unexpected memory info %+v", info)
+	}
+}
+
+func TestReadMemoryInfoCgroupV1Unlimited(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo": testMeminfo,
+		"sys/fs/cgroup/memory/memory.limit_in_bytes": "9223372036854771712\n",
+	})
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CgroupLimit != 0 {
+		t.Errorf("expected no cgroup limit, got %d", info.CgroupLimit)
+	}
+}
+
+func TestReadMemoryInfoCgroupV2(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo":                           testMeminfo,
+		"proc/self/cgroup":                       "0::/kubepods/pod1\n",
+		"sys/fs/cgroup/kubepods/pod1/memory.max": "2147483648\n",
+	})
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CgroupLimit != 2147483648 {
+		t.Errorf("expected a 2g cgroup limit, got %d", info.CgroupLimit)
+	}
+}
+
+func TestReadMemoryInfoCgroupV2Max(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo":             testMeminfo,
+		"proc/self/cgroup":         "0::/\n",
+		"sys/fs/cgroup/memory.max": "max\n",
+	})
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CgroupLimit != 0 {
+		t.Errorf("expected no cgroup limit, got %d", info.CgroupLimit)
+	}
+}
+
+func TestReadMemoryInfoWithoutMemAvailable(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo": "MemTotal: 1000 kB\nMemFree: 100 kB\nBuffers: 10 kB\nCached: 200 kB\n",
+	})
+	info, err := ReadMemoryInfo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Available != 310*1024 {
+		t.Errorf("expected MemFree + Buffers + Cached, got %d", info.Available)
+	}
+}
+
+func TestGetMemoryBasis(t *testing.T) {
+	root := writeFixture(t, map[string]string{
+		"proc/meminfo":             testMeminfo,
+		"sys/fs/cgroup/memory.max": "4294967296\n",
+	})
+	tests := []struct {
+		basis    string
+		expected uint64
+	}{
+		{MemoryBasisTotal, 16384000 * 1024},
+		{MemoryBasisAvailable, 4294967296},
+		{MemoryBasisCgroupLimit, 4294967296},
+		{"", 4294967296},
+	}
+	for _, test := range tests {
+		memory, err := GetMemory(root, test.basis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if memory != test.expected {
+			t.Errorf("basis %q: expected %d, got %d", test.basis, test.expected, memory)
+		}
+	}
+	if _, err := GetMemory(root, "free"); err == nil {
+		t.Error("expected an error for an unknown basis")
+	}
+}
+
+func TestReadMemoryInfoMissingMeminfo(t *testing.T) {
+	if _, err := ReadMemoryInfo(t.TempDir()); err == nil {
+		t.Error("expected an error without /proc/meminfo")
+	}
+}
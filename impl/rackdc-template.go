@@ -0,0 +1,32 @@
+package impl
+
+import (
+	"os"
+	"io/ioutil"
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+func initRackDCTemplate(templateFileName string, logger lg.Logger) {
+	if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
+		logger.Debug("Cassandra rackdc properties template does not exist so we are creating it", templateFileName)
+		err = ioutil.WriteFile(templateFileName, []byte(RackDCTemplate), 0644)
+		if err != nil {
+			logger.ErrorError("Unable to write template file "+templateFileName, err)
+		}
+	}
+}
+
+// RackDCTemplate is cassandra-rackdc.properties, read by GossipingPropertyFileSnitch.
+const RackDCTemplate = `# This file was generated with the template {{.RackDCTemplate}} by cassandra-cloud.
+
+# These properties are used with GossipingPropertyFileSnitch and will
+# indicate the rack and dc for this node
+dc={{.Datacenter}}
+rack={{.Rack}}
+{{if .DcSuffix}}
+# Add a suffix to a datacenter name.
+dc_suffix={{.DcSuffix}}
+{{end}}
+# Prefer the internal ip when possible, as the Ec2MultiRegionSnitch does.
+prefer_local={{.PreferLocal}}
+`
@@ -0,0 +1,175 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// How long the seed providers wait for a lookup.
+const SeedsTimeout = 5 * time.Second
+
+// SeedProvider turns one cluster_seeds entry, without its prefix, into seed addresses.
+type SeedProvider func(config *Config, value string) ([]string, error)
+
+// SeedProviders are the prefixes of cluster_seeds entries that are resolved when the config is loaded,
+// i.e., dns:cassandra-seeds.internal. Entries without a known prefix are used as they are.
+var SeedProviders = map[string]SeedProvider{
+	"dns":    dnsSeeds,
+	"srv":    srvSeeds,
+	"ec2":    ec2Seeds,
+	"consul": consulSeeds,
+}
+
+// initSeeds resolves cluster_seeds once, before any file is written, so a failed lookup stops cassandra-cloud
+// instead of leaving a cassandra.yaml without seeds.
+func initSeeds(config *Config, logger lg.Logger) error {
+	seeds, err := config.ResolveSeeds()
+	if err != nil {
+		return err
+	}
+	logger.Debug("Seeds", seeds)
+	config.Seeds = seeds
+	return nil
+}
+
+// ResolveSeeds resolves cluster_seeds for the seeds parameter of cassandra.yaml. The seeds are deduplicated, sorted
+// and limited to cluster_seeds_max_count.
+func (config *Config) ResolveSeeds() (string, error) {
+	var seeds []string
+	for _, entry := range strings.Split(config.ClusterSeeds, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		split := strings.SplitN(entry, ":", 2)
+		provider, ok := SeedProviders[strings.ToLower(split[0])]
+		if !ok || len(split) != 2 {
+			seeds = append(seeds, entry)
+			continue
+		}
+		resolved, err := provider(config, split[1])
+		if err != nil {
+			return "", fmt.Errorf("Unable to resolve the seeds %s: %s", entry, err)
+		}
+		seeds = append(seeds, resolved...)
+	}
+
+	seeds = sortSeeds(seeds)
+	if len(seeds) == 0 {
+		return "", fmt.Errorf("The seeds %s have no addresses", config.ClusterSeeds)
+	}
+	if config.SeedsMaxCount > 0 && len(seeds) > config.SeedsMaxCount {
+		seeds = seeds[:config.SeedsMaxCount]
+	}
+	return strings.Join(seeds, ","), nil
+}
+
+// sortSeeds removes duplicates and sorts IP addresses numerically, IPv4 before IPv6 and both before host names.
+func sortSeeds(seeds []string) []string {
+	unique := make(map[string]bool)
+	var sorted []string
+	for _, seed := range seeds {
+		if !unique[seed] {
+			unique[seed] = true
+			sorted = append(sorted, seed)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		first, second := seedIP(sorted[i]), seedIP(sorted[j])
+		if first == nil || second == nil {
+			if first != nil || second != nil {
+				return first != nil
+			}
+			return sorted[i] < sorted[j]
+		}
+		if (first.To4() == nil) != (second.To4() == nil) {
+			return first.To4() != nil
+		}
+		if compared := bytes.Compare(first.To16(), second.To16()); compared != 0 {
+			return compared < 0
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// seedIP parses a seed that can have a port, i.e., 10.0.0.1:7000 or [fd00::1]:7000.
+func seedIP(seed string) net.IP {
+	if host, _, err := net.SplitHostPort(seed); err == nil {
+		seed = host
+	}
+	return net.ParseIP(seed)
+}
+
+// seedsLookup is the part of net.Resolver that the dns and srv seed providers use.
+type seedsLookup interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// newSeedsResolver makes the resolver of the dns and srv seed providers, tests replace it with a stub.
+var newSeedsResolver = func(config *Config) seedsLookup {
+	return seedsResolver(config)
+}
+
+// seedsResolver uses the DNS server of cluster_seeds_resolver, host:port, or the resolver of the system.
+func seedsResolver(config *Config) *net.Resolver {
+	if config.SeedsResolver == "" {
+		return net.DefaultResolver
+	}
+	server := config.SeedsResolver
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// dnsSeeds uses every A and AAAA record of the name.
+func dnsSeeds(config *Config, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SeedsTimeout)
+	defer cancel()
+	return newSeedsResolver(config).LookupHost(ctx, name)
+}
+
+// srvSeeds uses the addresses of the targets of the SRV records, i.e., srv:_cassandra._tcp.prod.internal.
+// The port of a record is added to the address when it is not cluster_port and Cassandra is 4.0 or later,
+// since Cassandra 3.11 does not read ports in seeds.
+func srvSeeds(config *Config, name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SeedsTimeout)
+	defer cancel()
+	resolver := newSeedsResolver(config)
+	_, records, err := resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	withPort := CompareVersions(config.CassandraVersion, "4.0") >= 0
+	var seeds []string
+	for _, record := range records {
+		addresses, err := resolver.LookupHost(ctx, strings.TrimSuffix(record.Target, "."))
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range addresses {
+			if withPort && record.Port != 0 && int(record.Port) != config.ClusterPort {
+				address = net.JoinHostPort(address, strconv.Itoa(int(record.Port)))
+			}
+			seeds = append(seeds, address)
+		}
+	}
+	return seeds, nil
+}
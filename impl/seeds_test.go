@@ -0,0 +1,129 @@
+package impl
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// stubResolver answers seed lookups from maps instead of DNS.
+type stubResolver struct {
+	hosts map[string][]string
+	srv   map[string][]*net.SRV
+}
+
+func (resolver *stubResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addresses, found := resolver.hosts[host]
+	if !found {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addresses, nil
+}
+
+func (resolver *stubResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	records, found := resolver.srv[name]
+	if !found {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return name, records, nil
+}
+
+func stubSeedsResolver(t *testing.T, resolver *stubResolver) {
+	previous := newSeedsResolver
+	newSeedsResolver = func(config *Config) seedsLookup {
+		return resolver
+	}
+	t.Cleanup(func() {
+		newSeedsResolver = previous
+	})
+}
+
+func testSeedsResolver() *stubResolver {
+	return &stubResolver{
+		hosts: map[string][]string{
+			"seeds.internal":  {"10.0.0.12", "10.0.0.3", "fd00::1", "10.0.0.3"},
+			"node-a.internal": {"10.0.1.2"},
+			"node-b.internal": {"10.0.1.1"},
+		},
+		srv: map[string][]*net.SRV{
+			"_cassandra._tcp.internal": {
+				{Target: "node-a.internal.", Port: 7000},
+				{Target: "node-b.internal.", Port: 7001},
+			},
+		},
+	}
+}
+
+func TestResolveSeedsDNS(t *testing.T) {
+	stubSeedsResolver(t, testSeedsResolver())
+	config := &Config{ClusterSeeds: "dns:seeds.internal, 10.0.0.3, cassandra-0"}
+	seeds, err := config.ResolveSeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seeds != "10.0.0.3,10.0.0.12,fd00::1,cassandra-0" {
+		t.Errorf("expected sorted and deduplicated seeds, got %s", seeds)
+	}
+}
+
+func TestResolveSeedsMaxCount(t *testing.T) {
+	stubSeedsResolver(t, testSeedsResolver())
+	config := &Config{ClusterSeeds: "dns:seeds.internal", SeedsMaxCount: 2}
+	seeds, err := config.ResolveSeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seeds != "10.0.0.3,10.0.0.12" {
+		t.Errorf("expected the first two seeds, got %s", seeds)
+	}
+}
+
+func TestResolveSeedsSRV(t *testing.T) {
+	stubSeedsResolver(t, testSeedsResolver())
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"3.11", "10.0.1.1,10.0.1.2"},
+		{"4.0", "10.0.1.1:7001,10.0.1.2"},
+		{"4.1", "10.0.1.1:7001,10.0.1.2"},
+	}
+	for _, test := range tests {
+		config := &Config{ClusterSeeds: "srv:_cassandra._tcp.internal", ClusterPort: 7000, CassandraVersion: test.version}
+		seeds, err := config.ResolveSeeds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seeds != test.expected {
+			t.Errorf("Cassandra %s: expected %s, got %s", test.version, test.expected, seeds)
+		}
+	}
+}
+
+func TestResolveSeedsFailedLookup(t *testing.T) {
+	stubSeedsResolver(t, testSeedsResolver())
+	for _, clusterSeeds := range []string{"dns:missing.internal", "srv:_missing._tcp.internal", " , "} {
+		config := &Config{ClusterSeeds: clusterSeeds}
+		if seeds, err := config.ResolveSeeds(); err == nil {
+			t.Errorf("%q: expected an error, got %s", clusterSeeds, seeds)
+		}
+	}
+}
+
+func TestSortSeeds(t *testing.T) {
+	seeds := sortSeeds([]string{"node-b", "[fd00::2]:7000", "10.0.0.10", "10.0.0.9:7001", "fd00::1", "node-a", "10.0.0.10"})
+	expected := []string{"10.0.0.9:7001", "10.0.0.10", "fd00::1", "[fd00::2]:7000", "node-a", "node-b"}
+	if !reflect.DeepEqual(seeds, expected) {
+		t.Errorf("expected %v, got %v", expected, seeds)
+	}
+}
+
+func TestSeedsResolverServer(t *testing.T) {
+	if resolver := seedsResolver(&Config{}); resolver != net.DefaultResolver {
+		t.Error("expected the system resolver without cluster_seeds_resolver")
+	}
+	if resolver := seedsResolver(&Config{SeedsResolver: "10.0.0.2"}); resolver == net.DefaultResolver || resolver.Dial == nil {
+		t.Errorf("expected a resolver that dials cluster_seeds_resolver, got %v", resolver)
+	}
+}
@@ -0,0 +1,69 @@
+package impl
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The requests and signatures are from the AWS Signature Version 4 test suite.
+var testAwsCredentials = AwsCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+var testAwsTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSignV4(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		contentType   string
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{"get-vanilla", http.MethodGet, "", "", "host;x-amz-date",
+			"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"post-vanilla", http.MethodPost, "", "", "host;x-amz-date",
+			"5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"post-x-www-form-urlencoded", http.MethodPost, "application/x-www-form-urlencoded", "Param1=value1",
+			"content-type;host;x-amz-date", "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a"},
+	}
+	for _, test := range tests {
+		request, err := http.NewRequest(test.method, "https://example.amazonaws.com/", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.contentType != "" {
+			request.Header.Set("Content-Type", test.contentType)
+		}
+		SignV4(request, []byte(test.body), "us-east-1", "service", testAwsCredentials, testAwsTime)
+
+		expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=" +
+			test.signedHeaders + ", Signature=" + test.signature
+		if authorization := request.Header.Get("Authorization"); authorization != expected {
+			t.Errorf("%s: expected %s, got %s", test.name, expected, authorization)
+		}
+		if date := request.Header.Get("X-Amz-Date"); date != "20150830T123600Z" {
+			t.Errorf("%s: expected X-Amz-Date 20150830T123600Z, got %s", test.name, date)
+		}
+	}
+}
+
+func TestSignV4SessionToken(t *testing.T) {
+	request, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := testAwsCredentials
+	credentials.SessionToken = "session"
+	SignV4(request, nil, "us-east-1", "service", credentials, testAwsTime)
+	if request.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Error("expected the session token header")
+	}
+	if !strings.Contains(request.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("expected the session token to be signed, got %s", request.Header.Get("Authorization"))
+	}
+}
@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	Kibibyte uint64 = 1024
+	Mebibyte        = 1024 * Kibibyte
+	Gibibyte        = 1024 * Mebibyte
+	Tebibyte        = 1024 * Gibibyte
+
+	// AutoSize asks ergonomics to pick the size.
+	AutoSize Size = "AUTO"
+)
+
+// Size is a memory size setting such as 10GB, 10g, 10240MiB, 4859m or AUTO.
+// Sizes are normalized by ParseSize to the form the JVM expects, i.e., 10g or 4859m, so templates
+// can use {{.MaxHeapSize}} directly in -Xmx. All units are binary, so 1GB is 1024MB like the JVM.
+// Size has a string kind so HCL can decode it.
+type Size string
+
+// ParseSize validates a size string and returns it normalized to the JVM form.
+func ParseSize(value string) (Size, error) {
+	value = strings.TrimSpace(value)
+	if strings.ToUpper(value) == string(AutoSize) {
+		return AutoSize, nil
+	}
+	bytes, err := parseBytes(value)
+	if err != nil {
+		return "", err
+	}
+	return SizeOf(bytes), nil
+}
+
+// SizeOf returns the Size for a number of bytes using the largest JVM unit that divides it evenly.
+func SizeOf(bytes uint64) Size {
+	switch {
+	case bytes == 0:
+		return "0"
+	case bytes%Gibibyte == 0:
+		return Size(strconv.FormatUint(bytes/Gibibyte, 10) + "g")
+	case bytes%Mebibyte == 0:
+		return Size(strconv.FormatUint(bytes/Mebibyte, 10) + "m")
+	case bytes%Kibibyte == 0:
+		return Size(strconv.FormatUint(bytes/Kibibyte, 10) + "k")
+	default:
+		return Size(strconv.FormatUint(bytes, 10))
+	}
+}
+
+// IsAuto is true when the size is left to ergonomics.
+func (size Size) IsAuto() bool {
+	return strings.ToUpper(string(size)) == string(AutoSize)
+}
+
+// Bytes returns the size in bytes, or 0 if the size is AUTO or invalid.
+func (size Size) Bytes() uint64 {
+	if size.IsAuto() {
+		return 0
+	}
+	bytes, err := parseBytes(string(size))
+	if err != nil {
+		return 0
+	}
+	return bytes
+}
+
+// MB returns the size in whole mebibytes, the unit used by cassandra-env.sh.
+func (size Size) MB() uint64 {
+	return size.Bytes() / Mebibyte
+}
+
+// GB returns the size in whole gibibytes.
+func (size Size) GB() uint64 {
+	return size.Bytes() / Gibibyte
+}
+
+// JVM renders the size for -Xmx, -Xms and -Xmn, i.e., 4859m.
+func (size Size) JVM() string {
+	if size.IsAuto() {
+		return string(AutoSize)
+	}
+	return string(SizeOf(size.Bytes()))
+}
+
+func (size Size) String() string {
+	return string(size)
+}
+
+// Set implements flag.Value so sizes can be passed on the command line.
+func (size *Size) Set(value string) error {
+	parsed, err := ParseSize(value)
+	if err != nil {
+		return err
+	}
+	*size = parsed
+	return nil
+}
+
+func parseBytes(value string) (uint64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	number := strings.TrimRight(value, "kmgtib")
+	unit := value[len(number):]
+	if number == "" {
+		return 0, fmt.Errorf("Invalid size %q, expected a number with an optional unit, i.e., 10GB, 10g, 10240MiB", value)
+	}
+
+	size, err := strconv.ParseUint(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid size %q, expected a number with an optional unit, i.e., 10GB, 10g, 10240MiB", value)
+	}
+
+	var multiplier uint64
+	switch unit {
+	case "", "b":
+		multiplier = 1
+	case "k", "kb", "kib":
+		multiplier = Kibibyte
+	case "m", "mb", "mib":
+		multiplier = Mebibyte
+	case "g", "gb", "gib":
+		multiplier = Gibibyte
+	case "t", "tb", "tib":
+		multiplier = Tebibyte
+	default:
+		return 0, fmt.Errorf("Invalid size unit %q in %q, expected k, m, g or t with an optional B or iB", unit, value)
+	}
+	if size > ^uint64(0)/multiplier {
+		return 0, fmt.Errorf("Size %q is too large", value)
+	}
+	return size * multiplier, nil
+}
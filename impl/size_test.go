@@ -0,0 +1,87 @@
+package impl
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Size
+		valid    bool
+	}{
+		{"10GB", "10g", true},
+		{"10g", "10g", true},
+		{"10GiB", "10g", true},
+		{"10240MiB", "10g", true},
+		{"10240m", "10g", true},
+		{"4859m", "4859m", true},
+		{"4859MB", "4859m", true},
+		{" 512k ", "512k", true},
+		{"1t", "1024g", true},
+		{"1000", "1000", true},
+		{"AUTO", AutoSize, true},
+		{"auto", AutoSize, true},
+		{"0", "0", true},
+		{"0g", "0", true},
+		{"", "", false},
+		{"g", "", false},
+		{"ten", "", false},
+		{"10x", "", false},
+		{"10 GB", "", false},
+		{"-1g", "", false},
+		{"1.5g", "", false},
+		{"99999999999t", "", false},
+	}
+	for _, test := range tests {
+		size, err := ParseSize(test.value)
+		if (err == nil) != test.valid || size != test.expected {
+			t.Errorf("%q: expected %q (valid %v), got %q and %v", test.value, test.expected, test.valid, size, err)
+		}
+	}
+}
+
+func TestNormalizeSize(t *testing.T) {
+	tests := []struct {
+		value    Size
+		expected Size
+		valid    bool
+	}{
+		{"10GB", "10g", true},
+		{"10240MiB", "10g", true},
+		{"4859m", "4859m", true},
+		{"auto", AutoSize, true},
+		{"0", "0", false},
+		{"0MB", "0MB", false},
+		{"lots", "lots", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		size := test.value
+		err := normalizeSize("max_heap_size", &size)
+		if (err == nil) != test.valid || size != test.expected {
+			t.Errorf("%q: expected %q (valid %v), got %q and %v", test.value, test.expected, test.valid, size, err)
+		}
+	}
+}
+
+func TestSizeUnits(t *testing.T) {
+	tests := []struct {
+		size Size
+		mb   uint64
+		gb   uint64
+		jvm  string
+	}{
+		{"10g", 10240, 10, "10g"},
+		{"10240MiB", 10240, 10, "10g"},
+		{"4859m", 4859, 4, "4859m"},
+		{"1536k", 1, 0, "1536k"},
+		{"512k", 0, 0, "512k"},
+		{AutoSize, 0, 0, "AUTO"},
+		{"garbage", 0, 0, "0"},
+	}
+	for _, test := range tests {
+		if test.size.MB() != test.mb || test.size.GB() != test.gb || test.size.JVM() != test.jvm {
+			t.Errorf("%q: expected %dMB, %dGB and %s, got %dMB, %dGB and %s", test.size, test.mb, test.gb, test.jvm,
+				test.size.MB(), test.size.GB(), test.size.JVM())
+		}
+	}
+}
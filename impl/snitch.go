@@ -0,0 +1,34 @@
+package impl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UsesSnitch is true if the snitch is name, i.e., GossipingPropertyFileSnitch or
+// org.apache.cassandra.locator.GossipingPropertyFileSnitch.
+func (config *Config) UsesSnitch(name string) bool {
+	snitch := config.Snitch
+	if index := strings.LastIndex(snitch, "."); index >= 0 {
+		snitch = snitch[index+1:]
+	}
+	return strings.EqualFold(snitch, name)
+}
+
+// validateSnitch checks that the files the snitch reads can be generated.
+func validateSnitch(config *Config) error {
+	if config.UsesSnitch("GossipingPropertyFileSnitch") {
+		if strings.TrimSpace(config.Datacenter) == "" || strings.TrimSpace(config.Rack) == "" {
+			return fmt.Errorf("GossipingPropertyFileSnitch needs datacenter and rack to write %s", config.RackDCFileName)
+		}
+		for name, value := range map[string]string{"datacenter": config.Datacenter, "rack": config.Rack, "dc_suffix": config.DcSuffix} {
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("%s can't contain a line break", name)
+			}
+		}
+	}
+	if config.UsesSnitch("PropertyFileSnitch") {
+		return validateTopology(config)
+	}
+	return nil
+}
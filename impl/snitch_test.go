@@ -0,0 +1,47 @@
+package impl
+
+import "testing"
+
+func TestUsesSnitch(t *testing.T) {
+	tests := []struct {
+		snitch   string
+		name     string
+		expected bool
+	}{
+		{"GossipingPropertyFileSnitch", "GossipingPropertyFileSnitch", true},
+		{"org.apache.cassandra.locator.GossipingPropertyFileSnitch", "GossipingPropertyFileSnitch", true},
+		{"gossipingpropertyfilesnitch", "GossipingPropertyFileSnitch", true},
+		{"GossipingPropertyFileSnitch", "PropertyFileSnitch", false},
+		{"org.apache.cassandra.locator.PropertyFileSnitch", "PropertyFileSnitch", true},
+		{"Ec2Snitch", "PropertyFileSnitch", false},
+		{"com.example.MyPropertyFileSnitch", "PropertyFileSnitch", false},
+		{"", "SimpleSnitch", false},
+	}
+	for _, test := range tests {
+		config := &Config{Snitch: test.snitch}
+		if actual := config.UsesSnitch(test.name); actual != test.expected {
+			t.Errorf("%q uses %s: expected %v, got %v", test.snitch, test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestValidateSnitch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"rackdc", Config{Snitch: "GossipingPropertyFileSnitch", Datacenter: "dc1", Rack: "rack1"}, true},
+		{"no rack", Config{Snitch: "GossipingPropertyFileSnitch", Datacenter: "dc1", Rack: " "}, false},
+		{"no datacenter", Config{Snitch: "org.apache.cassandra.locator.GossipingPropertyFileSnitch", Rack: "rack1"}, false},
+		{"line break", Config{Snitch: "GossipingPropertyFileSnitch", Datacenter: "dc1\nprefer_local=true", Rack: "rack1"}, false},
+		{"dc_suffix line break", Config{Snitch: "GossipingPropertyFileSnitch", Datacenter: "dc1", Rack: "rack1",
+			DcSuffix: "_a\r"}, false},
+		{"other snitch", Config{Snitch: "SimpleSnitch"}, true},
+	}
+	for _, test := range tests {
+		if err := validateSnitch(&test.config); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.name, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs are the functions available in every template. The last argument of each function is the
+// one that can be piped, i.e., {{.ClusterSeeds | split ","}} or {{.MaxHeapSize | toMB}}.
+// When the template data is a *Config, cpuCount and memoryMB use its system root, memory basis and CPU count.
+func TemplateFuncs(data interface{}) template.FuncMap {
+	systemRoot, memoryBasis, configCPUs := "/", MemoryBasisCgroupLimit, 0
+	if config, ok := data.(*Config); ok {
+		systemRoot, memoryBasis, configCPUs = config.SystemRoot, config.MemoryBasis, config.CpuCount
+	}
+
+	return template.FuncMap{
+		"split": func(separator string, value string) []string {
+			if value == "" {
+				return []string{}
+			}
+			return strings.Split(value, separator)
+		},
+		"join": func(separator string, list interface{}) string {
+			return strings.Join(templateStrings(list), separator)
+		},
+		"default": func(defaultValue interface{}, value interface{}) interface{} {
+			if isEmptyTemplateValue(value) {
+				return defaultValue
+			}
+			return value
+		},
+		"quote": func(value interface{}) string {
+			return strconv.Quote(fmt.Sprint(value))
+		},
+		"env": os.Getenv,
+		"toMB": func(value interface{}) (uint64, error) {
+			size, err := templateSize(value)
+			return size.MB(), err
+		},
+		"toGB": func(value interface{}) (uint64, error) {
+			size, err := templateSize(value)
+			return size.GB(), err
+		},
+		"ipOf":     InterfaceAddress,
+		"hostname": os.Hostname,
+		"cpuCount": func() int {
+			if configCPUs > 0 {
+				return configCPUs
+			}
+			return GetCPUCount(systemRoot)
+		},
+		"memoryMB": func() (uint64, error) {
+			memory, err := GetMemory(systemRoot, memoryBasis)
+			return memory / Mebibyte, err
+		},
+		"compareVersions": CompareVersions,
+		"yamlList": func(list interface{}) (string, error) {
+			// A nil slice would be rendered as null, which YAML reads as no list.
+			values := templateStrings(list)
+			if values == nil {
+				values = []string{}
+			}
+			bytes, err := json.Marshal(values)
+			return string(bytes), err
+		},
+		"shellEscape": shellEscape,
+	}
+}
+
+// shellDoubleQuoted escapes the characters that keep their meaning inside double quotes in sh.
+var shellDoubleQuoted = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+// shellEscape escapes a value so it is taken literally inside double quotes in a shell script,
+// i.e., JVM_OPTS="$JVM_OPTS {{shellEscape .}}".
+func shellEscape(value interface{}) string {
+	return shellDoubleQuoted.Replace(fmt.Sprint(value))
+}
+
+// templateStrings turns a string, []string or any other slice into a list of strings.
+func templateStrings(list interface{}) []string {
+	switch typed := list.(type) {
+	case []string:
+		return typed
+	case string:
+		return []string{typed}
+	}
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return []string{fmt.Sprint(list)}
+	}
+	strings := make([]string, value.Len())
+	for index := range strings {
+		strings[index] = fmt.Sprint(value.Index(index).Interface())
+	}
+	return strings
+}
+
+func templateSize(value interface{}) (Size, error) {
+	switch typed := value.(type) {
+	case Size:
+		return typed, nil
+	case string:
+		return ParseSize(typed)
+	case int:
+		return SizeOf(uint64(typed)), nil
+	case uint64:
+		return SizeOf(typed), nil
+	}
+	return "", fmt.Errorf("Unable to convert %v to a size", value)
+}
+
+func isEmptyTemplateValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return reflected.Len() == 0
+	}
+	return reflect.DeepEqual(value, reflect.Zero(reflected.Type()).Interface())
+}
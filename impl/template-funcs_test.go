@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	config := &Config{ClusterSeeds: "10.0.0.1,10.0.0.2", DataDirs: []string{"/data1", "/data2"}, MaxHeapSize: "8g",
+		CpuCount: 6}
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`{{range split "," .ClusterSeeds}}[{{.}}]{{end}}`, "[10.0.0.1][10.0.0.2]"},
+		{`{{.ClusterSeeds | split "," | len}}`, "2"},
+		{`{{split "," .ReplaceAddress | len}}`, "0"},
+		{`{{join ";" .DataDirs}}`, "/data1;/data2"},
+		{`{{.ReplaceAddress | default "none"}}`, "none"},
+		{`{{.ClusterSeeds | default "none"}}`, "10.0.0.1,10.0.0.2"},
+		{`{{.NumTokens | default 256}}`, "256"},
+		{`{{.ExtraJvmOpts | default "none"}}`, "none"},
+		{`{{.DataDirs | default "none"}}`, "[/data1 /data2]"},
+		{`{{quote "My Cluster"}}`, `"My Cluster"`},
+		{`{{toMB .MaxHeapSize}}`, "8192"},
+		{`{{.MaxHeapSize | toGB}}`, "8"},
+		{`{{toMB "10240MiB"}}`, "10240"},
+		{`{{toMB 1048576}}`, "1"},
+		{`{{yamlList .DataDirs}}`, `["/data1","/data2"]`},
+		{`{{yamlList "/data"}}`, `["/data"]`},
+		{`{{yamlList .ExtraJvmOpts}}`, `[]`},
+		{`{{compareVersions "4.0.11" "4.1"}}`, "-1"},
+		{`{{cpuCount}}`, "6"},
+	}
+	for _, test := range tests {
+		if rendered := renderTemplate(t, test.text, config); rendered != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.text, test.expected, rendered)
+		}
+	}
+}
+
+func TestTemplateFuncsToMBErrors(t *testing.T) {
+	toMB := TemplateFuncs(&Config{})["toMB"].(func(interface{}) (uint64, error))
+	for _, value := range []interface{}{"lots", 1.5, nil} {
+		if _, err := toMB(value); err == nil {
+			t.Errorf("%v: expected an error", value)
+		}
+	}
+}
+
+func TestTemplateStrings(t *testing.T) {
+	tests := []struct {
+		list     interface{}
+		expected []string
+	}{
+		{[]string{"a", "b"}, []string{"a", "b"}},
+		{"a", []string{"a"}},
+		{[]int{1, 2}, []string{"1", "2"}},
+		{[2]bool{true, false}, []string{"true", "false"}},
+		{42, []string{"42"}},
+	}
+	for _, test := range tests {
+		if actual := templateStrings(test.list); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: expected %v, got %v", test.list, test.expected, actual)
+		}
+	}
+}
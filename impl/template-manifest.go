@@ -0,0 +1,198 @@
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// TemplateFile is one entry of the template manifest, a template block in cloud.conf.
+type TemplateFile struct {
+	//Name of the entry. An entry with the name of a built in template changes the fields it sets, i.e., cassandra-yaml.
+	Name string `hcl:",key"`
+	//Location of the template.
+	Source string `hcl:"source"`
+	//Location of the generated file.
+	Dest string `hcl:"dest"`
+	//Octal file mode, i.e., 0755. Defaults to 0644.
+	Mode string `hcl:"mode"`
+	//user or user:group that owns the generated file. Defaults to the user running cassandra-cloud.
+	Owner string `hcl:"owner"`
+	//true, false or a template condition, i.e., {{eq .GC "G1"}}. Defaults to true.
+	Enabled string `hcl:"enabled"`
+}
+
+// builtInTemplates are the files cassandra-cloud always knows how to generate.
+func builtInTemplates(config *Config) []TemplateFile {
+	return []TemplateFile{
+		{Name: "cassandra-yaml", Source: config.YamlConfigTemplate, Dest: config.YamlConfigFileName,
+			Enabled: `{{ne .YamlMode "patch"}}`},
+		{Name: "cassandra-env", Source: config.CassandraEnvTemplate, Dest: config.CassandraEnvFileName, Mode: "0755"},
+		{Name: "logback", Source: config.LogbackTemplate, Dest: config.LogbackFileName},
+		{Name: "rackdc", Source: config.RackDCTemplate, Dest: config.RackDCFileName,
+			Enabled: `{{.UsesSnitch "GossipingPropertyFileSnitch"}}`},
+		{Name: "topology", Source: config.TopologyTemplate, Dest: config.TopologyFileName,
+			Enabled: `{{.UsesSnitch "PropertyFileSnitch"}}`},
+		{Name: "jvm-options", Source: config.JvmOptionsTemplate, Dest: config.JvmOptionsFileName,
+			Enabled: `{{eq .JvmOptionsLayout "single"}}`},
+		{Name: "jvm-server-options", Source: config.JvmServerOptionsTemplate, Dest: config.JvmServerOptionsFileName,
+			Enabled: `{{eq .JvmOptionsLayout "split"}}`},
+		{Name: "jvm8-server-options", Source: config.Jvm8ServerOptionsTemplate, Dest: config.Jvm8ServerOptionsFileName,
+			Enabled: `{{eq .JvmOptionsLayout "split"}}`},
+		{Name: "jvm11-server-options", Source: config.Jvm11ServerOptionsTemplate, Dest: config.Jvm11ServerOptionsFileName,
+			Enabled: `{{eq .JvmOptionsLayout "split"}}`},
+		{Name: "jvm17-server-options", Source: config.Jvm17ServerOptionsTemplate, Dest: config.Jvm17ServerOptionsFileName,
+			Enabled: `{{and (eq .JvmOptionsLayout "split") (ge (compareVersions .CassandraVersion "5.0") 0)}}`},
+	}
+}
+
+// initTemplateManifest puts the built in templates in front of the template blocks from cloud.conf.
+// A template block with the same name as a built in template changes the fields it sets and keeps the others,
+// i.e., the 0755 mode of cassandra-env.
+func initTemplateManifest(config *Config, logger lg.Logger) {
+	configured := make(map[string]TemplateFile)
+	for _, templateFile := range config.Templates {
+		configured[templateFile.Name] = templateFile
+	}
+
+	var manifest []TemplateFile
+	for _, builtIn := range builtInTemplates(config) {
+		if templateFile, ok := configured[builtIn.Name]; ok {
+			logger.Debug("Template", builtIn.Name, "changes the built in template")
+			manifest = append(manifest, builtIn.merge(templateFile))
+			delete(configured, builtIn.Name)
+			continue
+		}
+		manifest = append(manifest, builtIn)
+	}
+	for _, templateFile := range config.Templates {
+		if _, ok := configured[templateFile.Name]; ok {
+			manifest = append(manifest, templateFile)
+		}
+	}
+	config.Templates = manifest
+}
+
+// merge returns the template with the fields that are set in other.
+func (templateFile TemplateFile) merge(other TemplateFile) TemplateFile {
+	if other.Source != "" {
+		templateFile.Source = other.Source
+	}
+	if other.Dest != "" {
+		templateFile.Dest = other.Dest
+	}
+	if other.Mode != "" {
+		templateFile.Mode = other.Mode
+	}
+	if other.Owner != "" {
+		templateFile.Owner = other.Owner
+	}
+	if other.Enabled != "" {
+		templateFile.Enabled = other.Enabled
+	}
+	return templateFile
+}
+
+// ProcessTemplates renders every enabled template of the manifest. It keeps going when one fails
+// and returns an error that names every template that failed.
+func ProcessTemplates(config *Config, logger lg.Logger) error {
+	var failed []string
+	for _, templateFile := range config.Templates {
+		if err := templateFile.Process(config, logger); err != nil {
+			failed = append(failed, templateFile.Name+": "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Unable to process the templates %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Process renders the template if it is enabled and sets the mode and owner of the generated file.
+func (templateFile TemplateFile) Process(config *Config, logger lg.Logger) error {
+	enabled, err := templateFile.IsEnabled(config)
+	if err != nil {
+		logger.ErrorError("Unable to check if template "+templateFile.Name+" is enabled", err)
+		return err
+	}
+	if !enabled {
+		logger.Debug("Template", templateFile.Name, "is not enabled")
+		return nil
+	}
+	if templateFile.Source == "" || templateFile.Dest == "" {
+		err = fmt.Errorf("Template %s needs a source and a dest", templateFile.Name)
+		logger.ErrorError("Unable to process template", err)
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if templateFile.Mode != "" {
+		parsed, err := strconv.ParseUint(templateFile.Mode, 8, 32)
+		if err != nil {
+			logger.ErrorError("Unable to parse the mode of template "+templateFile.Name, err)
+			return err
+		}
+		mode = os.FileMode(parsed)
+	}
+
+	logger.Debug("Processing template", templateFile.Name, templateFile.Source, "to", templateFile.Dest)
+	if err := os.MkdirAll(filepath.Dir(templateFile.Dest), 0755); err != nil {
+		logger.ErrorError("Unable to create the directory of "+templateFile.Dest, err)
+		return err
+	}
+	return ProcessTemplateFile(templateFile.Source, templateFile.Dest, config, mode, templateFile.Owner, logger)
+}
+
+// IsEnabled renders the enabled condition with the config and parses the result as a bool.
+func (templateFile TemplateFile) IsEnabled(config *Config) (bool, error) {
+	if strings.TrimSpace(templateFile.Enabled) == "" {
+		return true, nil
+	}
+	condition, err := template.New(templateFile.Name).Funcs(TemplateFuncs(config)).Parse(templateFile.Enabled)
+	if err != nil {
+		return false, err
+	}
+	var result bytes.Buffer
+	if err := condition.Execute(&result, config); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(strings.TrimSpace(result.String()))
+}
+
+// chownFile sets the owner of a file from user or user:group, names or numeric ids.
+func chownFile(fileName string, owner string) error {
+	split := strings.SplitN(owner, ":", 2)
+	uid, gid := -1, -1
+
+	if split[0] != "" {
+		if id, err := strconv.Atoi(split[0]); err == nil {
+			uid = id
+		} else {
+			found, err := user.Lookup(split[0])
+			if err != nil {
+				return err
+			}
+			uid, _ = strconv.Atoi(found.Uid)
+			gid, _ = strconv.Atoi(found.Gid)
+		}
+	}
+	if len(split) == 2 && split[1] != "" {
+		if id, err := strconv.Atoi(split[1]); err == nil {
+			gid = id
+		} else {
+			found, err := user.LookupGroup(split[1])
+			if err != nil {
+				return err
+			}
+			gid, _ = strconv.Atoi(found.Gid)
+		}
+	}
+	return os.Chown(fileName, uid, gid)
+}
@@ -0,0 +1,125 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInitTemplateManifestMerge(t *testing.T) {
+	config := &Config{
+		CassandraEnvTemplate: "/conf/cassandra-env.template",
+		CassandraEnvFileName: "/conf/cassandra-env.sh",
+		Templates: []TemplateFile{
+			{Name: "cassandra-env", Owner: "cassandra"},
+			{Name: "jmx-password", Source: "/conf/jmx.template", Dest: "/conf/jmxremote.password", Mode: "0600"},
+		},
+	}
+	initTemplateManifest(config, testLogger())
+
+	var env, password *TemplateFile
+	for index := range config.Templates {
+		switch config.Templates[index].Name {
+		case "cassandra-env":
+			env = &config.Templates[index]
+		case "jmx-password":
+			password = &config.Templates[index]
+		}
+	}
+	expected := TemplateFile{Name: "cassandra-env", Source: "/conf/cassandra-env.template", Dest: "/conf/cassandra-env.sh",
+		Mode: "0755", Owner: "cassandra"}
+	if env == nil || !reflect.DeepEqual(*env, expected) {
+		t.Errorf("expected the built in template with the owner set, got %+v", env)
+	}
+	if password == nil || password.Mode != "0600" {
+		t.Errorf("expected the jmx-password template after the built in ones, got %+v", password)
+	}
+	if last := config.Templates[len(config.Templates)-1]; last.Name != "jmx-password" {
+		t.Errorf("expected the template blocks after the built in templates, got %s last", last.Name)
+	}
+}
+
+func TestTemplateFileIsEnabled(t *testing.T) {
+	config := &Config{GC: GCG1, YamlMode: "patch", Snitch: "GossipingPropertyFileSnitch"}
+	tests := []struct {
+		enabled  string
+		expected bool
+		valid    bool
+	}{
+		{"", true, true},
+		{"true", true, true},
+		{" false ", false, true},
+		{`{{eq .GC "G1"}}`, true, true},
+		{`{{ne .YamlMode "patch"}}`, false, true},
+		{`{{.UsesSnitch "GossipingPropertyFileSnitch"}}`, true, true},
+		{"{{.GC}}", false, false},
+		{"{{if}}", false, false},
+	}
+	for _, test := range tests {
+		enabled, err := TemplateFile{Name: "test", Enabled: test.enabled}.IsEnabled(config)
+		if (err == nil) != test.valid || enabled != test.expected {
+			t.Errorf("%q: expected %v (valid %v), got %v and %v", test.enabled, test.expected, test.valid, enabled, err)
+		}
+	}
+}
+
+func TestTemplateFileProcessMode(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "jmx.template")
+	if err := ioutil.WriteFile(source, []byte("monitorRole {{.ClusterName}}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "conf", "jmxremote.password")
+	templateFile := TemplateFile{Name: "jmx-password", Source: source, Dest: dest, Mode: "0600"}
+	if err := templateFile.Process(&Config{ClusterName: "secret"}, testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+	if contents, _ := ioutil.ReadFile(dest); string(contents) != "monitorRole secret\n" {
+		t.Errorf("unexpected contents %q", contents)
+	}
+}
+
+func TestTemplateFileProcessFailures(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "broken.template")
+	if err := ioutil.WriteFile(source, []byte("{{.NoSuchField}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(dir, "valid.template")
+	if err := ioutil.WriteFile(valid, []byte("ok\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []TemplateFile{
+		{Name: "broken", Source: source, Dest: filepath.Join(dir, "broken")},
+		{Name: "owner", Source: valid, Dest: filepath.Join(dir, "owner"), Owner: "no-such-user-for-cassandra-cloud"},
+		{Name: "mode", Source: valid, Dest: filepath.Join(dir, "mode"), Mode: "rw"},
+		{Name: "no-dest", Source: valid},
+	}
+	for _, templateFile := range tests {
+		if err := templateFile.Process(&Config{}, testLogger()); err == nil {
+			t.Errorf("%s: expected an error", templateFile.Name)
+		}
+		if templateFile.Dest != "" {
+			if _, err := os.Stat(templateFile.Dest); !os.IsNotExist(err) {
+				t.Errorf("%s: expected no file to be written, got %v", templateFile.Name, err)
+			}
+		}
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, ".*")); len(leftovers) != 0 {
+		t.Errorf("expected the temporary files to be removed, got %v", leftovers)
+	}
+
+	config := &Config{Templates: tests}
+	if err := ProcessTemplates(config, testLogger()); err == nil {
+		t.Error("expected ProcessTemplates to report the failed templates")
+	}
+}
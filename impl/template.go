@@ -4,11 +4,19 @@ import (
 	"os"
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"text/template"
 	lg "github.com/advantageous/go-logback/logging"
 )
 
 func ProcessTemplate(inputFileName string, outputFileName string, any interface{}, logger lg.Logger) error {
+	return ProcessTemplateFile(inputFileName, outputFileName, any, 0644, "", logger)
+}
+
+// ProcessTemplateFile renders the template and gives the output file its mode and owner, user or user:group,
+// before it is moved into place, so a file such as the JMX password file is never readable by others.
+func ProcessTemplateFile(inputFileName string, outputFileName string, any interface{}, mode os.FileMode, owner string,
+	logger lg.Logger) error {
 	bytes, err := ioutil.ReadFile(inputFileName)
 	if err != nil {
 		logger.Errorf("Unable to load template %s  \n", inputFileName)
@@ -16,28 +24,44 @@ func ProcessTemplate(inputFileName string, outputFileName string, any interface{
 		return err
 	}
 
-	theTemplate, err := template.New("test").Parse(string(bytes))
+	theTemplate, err := template.New("test").Funcs(TemplateFuncs(any)).Parse(string(bytes))
 	if err != nil {
 		logger.Errorf("Unable to parse template %s  \n", inputFileName)
 		logger.ErrorError("Error was", err)
 		return err
 	}
 
-
-	//funcMap := template.FuncMap{
-	//			// The name "title" is what the function will be called in the template text.
-	//				"title": strings.Title,
-	//			}
-	//
-	//theTemplate.Funcs(funcMap)
-
-	outputFile, err := os.Create(outputFileName)
+	// Render next to the output file and rename it into place, so a failed template never leaves a partial file.
+	outputFile, err := ioutil.TempFile(filepath.Dir(outputFileName), "."+filepath.Base(outputFileName)+".")
 	if err != nil {
 		logger.ErrorError(fmt.Sprintf("Unable to open output file %s", outputFileName), err)
 		return err
 	}
-	defer outputFile.Close()
-	theTemplate.Execute(outputFile, any)
+	defer os.Remove(outputFile.Name())
+	if err := theTemplate.Execute(outputFile, any); err != nil {
+		outputFile.Close()
+		logger.Errorf("Unable to execute template %s  \n", inputFileName)
+		logger.ErrorError("Error was", err)
+		return err
+	}
+	if err := outputFile.Close(); err != nil {
+		logger.ErrorError(fmt.Sprintf("Unable to write output file %s", outputFileName), err)
+		return err
+	}
+	if err := os.Chmod(outputFile.Name(), mode); err != nil {
+		logger.ErrorError(fmt.Sprintf("Unable to set the mode of output file %s", outputFileName), err)
+		return err
+	}
+	if owner != "" {
+		if err := chownFile(outputFile.Name(), owner); err != nil {
+			logger.ErrorError(fmt.Sprintf("Unable to set the owner of output file %s", outputFileName), err)
+			return err
+		}
+	}
+	if err := os.Rename(outputFile.Name(), outputFileName); err != nil {
+		logger.ErrorError(fmt.Sprintf("Unable to write output file %s", outputFileName), err)
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,109 @@
+package impl
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"io/ioutil"
+	"strings"
+	lg "github.com/advantageous/go-logback/logging"
+)
+
+// Topology is the topology block of cloud.conf that PropertyFileSnitch reads from cassandra-topology.properties.
+type Topology struct {
+	//Data center of nodes that are not listed. Defaults to datacenter.
+	DefaultDatacenter string `hcl:"default_datacenter"`
+	//Rack of nodes that are not listed. Defaults to rack.
+	DefaultRack string `hcl:"default_rack"`
+	Nodes []TopologyNode `hcl:"node"`
+}
+
+// TopologyNode is a node block, i.e., node "10.0.0.1" { datacenter = "us-east" rack = "rack1" }.
+type TopologyNode struct {
+	Address    string `hcl:",key"`
+	Datacenter string `hcl:"datacenter"`
+	Rack       string `hcl:"rack"`
+}
+
+// Key is the address as a properties key, the colons of IPv6 addresses have to be escaped.
+func (node TopologyNode) Key() string {
+	return strings.Replace(node.Address, ":", `\:`, -1)
+}
+
+// validateTopology checks the topology block and that this node is listed in it.
+func validateTopology(config *Config) error {
+	topology := &config.Topology
+	if topology.DefaultDatacenter == "" {
+		topology.DefaultDatacenter = config.Datacenter
+	}
+	if topology.DefaultRack == "" {
+		topology.DefaultRack = config.Rack
+	}
+	if topology.DefaultDatacenter == "" || topology.DefaultRack == "" {
+		return fmt.Errorf("PropertyFileSnitch needs default_datacenter and default_rack in the topology block")
+	}
+
+	for _, node := range topology.Nodes {
+		if net.ParseIP(node.Address) == nil {
+			return fmt.Errorf("Topology node %q is not an IP address", node.Address)
+		}
+		if node.Datacenter == "" || node.Rack == "" {
+			return fmt.Errorf("Topology node %s needs a datacenter and a rack", node.Address)
+		}
+	}
+
+	localAddress, err := localClusterAddress(config)
+	if err != nil {
+		return err
+	}
+	for _, node := range topology.Nodes {
+		if net.ParseIP(node.Address).Equal(localAddress) {
+			return nil
+		}
+	}
+	return fmt.Errorf("The address of this node %s is not in the topology block", localAddress)
+}
+
+// localClusterAddress is the address other nodes know this node by: the broadcast address,
+// the listen address or the address of the listen interface.
+func localClusterAddress(config *Config) (net.IP, error) {
+	address := config.ClusterBroadcastAddress
+	if address == "" || address == "localhost" {
+		address = config.ClusterListenAddress
+	}
+	if config.ClusterListenInterface != "" && (address == "" || address == "localhost") {
+		interfaceAddress, err := PreferredInterfaceAddress(config.ClusterListenInterface, config.InterfaceIPPreference)
+		if err != nil {
+			return nil, err
+		}
+		address = interfaceAddress
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip, nil
+	}
+	addresses, err := net.LookupHost(address)
+	if err != nil || len(addresses) == 0 {
+		return nil, fmt.Errorf("Unable to resolve the address of this node %s", address)
+	}
+	return net.ParseIP(addresses[0]), nil
+}
+
+func initTopologyTemplate(templateFileName string, logger lg.Logger) {
+	if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
+		logger.Debug("Cassandra topology properties template does not exist so we are creating it", templateFileName)
+		err = ioutil.WriteFile(templateFileName, []byte(TopologyTemplate), 0644)
+		if err != nil {
+			logger.ErrorError("Unable to write template file "+templateFileName, err)
+		}
+	}
+}
+
+// TopologyTemplate is cassandra-topology.properties, read by PropertyFileSnitch. Every node gets the same file.
+const TopologyTemplate = `# This file was generated with the template {{.TopologyTemplate}} by cassandra-cloud.
+
+# Cassandra Node IP=Data Center:Rack
+{{range .Topology.Nodes}}{{.Key}}={{.Datacenter}}:{{.Rack}}
+{{end}}
+# default for unknown nodes
+default={{.Topology.DefaultDatacenter}}:{{.Topology.DefaultRack}}
+`
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTopology(t *testing.T) {
+	nodes := []TopologyNode{
+		{Address: "10.0.0.1", Datacenter: "us-east", Rack: "rack1"},
+		{Address: "fd00::2", Datacenter: "us-west", Rack: "rack2"},
+	}
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{"broadcast address", Config{ClusterBroadcastAddress: "10.0.0.1", ClusterListenAddress: "10.9.9.9",
+			Datacenter: "dc1", Rack: "rack1", Topology: Topology{Nodes: nodes}}, true},
+		{"listen address", Config{ClusterListenAddress: "10.0.0.1", Datacenter: "dc1", Rack: "rack1",
+			Topology: Topology{Nodes: nodes}}, true},
+		{"IPv6", Config{ClusterListenAddress: "fd00:0::2", Topology: Topology{DefaultDatacenter: "dc1",
+			DefaultRack: "rack1", Nodes: nodes}}, true},
+		{"not listed", Config{ClusterListenAddress: "10.0.0.3", Datacenter: "dc1", Rack: "rack1",
+			Topology: Topology{Nodes: nodes}}, false},
+		{"no default", Config{ClusterListenAddress: "10.0.0.1", Datacenter: "dc1", Topology: Topology{Nodes: nodes}}, false},
+		{"host name node", Config{ClusterListenAddress: "10.0.0.1", Datacenter: "dc1", Rack: "rack1",
+			Topology: Topology{Nodes: append([]TopologyNode{{Address: "cassandra-0", Datacenter: "dc1", Rack: "rack1"}},
+				nodes...)}}, false},
+		{"node without rack", Config{ClusterListenAddress: "10.0.0.1", Datacenter: "dc1", Rack: "rack1",
+			Topology: Topology{Nodes: append([]TopologyNode{{Address: "10.0.0.4", Datacenter: "dc1"}}, nodes...)}}, false},
+	}
+	for _, test := range tests {
+		if err := validateTopology(&test.config); (err == nil) != test.valid {
+			t.Errorf("%s: expected valid %v, got %v", test.name, test.valid, err)
+		}
+	}
+
+	config := &Config{ClusterListenAddress: "10.0.0.1", Datacenter: "dc1", Rack: "rack1", Topology: Topology{Nodes: nodes}}
+	if err := validateTopology(config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Topology.DefaultDatacenter != "dc1" || config.Topology.DefaultRack != "rack1" {
+		t.Errorf("expected the defaults from datacenter and rack, got %s and %s",
+			config.Topology.DefaultDatacenter, config.Topology.DefaultRack)
+	}
+}
+
+func TestTopologyTemplate(t *testing.T) {
+	config := &Config{Topology: Topology{DefaultDatacenter: "dc1", DefaultRack: "rack1", Nodes: []TopologyNode{
+		{Address: "10.0.0.1", Datacenter: "us-east", Rack: "rack1"},
+		{Address: "fd00::2", Datacenter: "us-west", Rack: "rack2"},
+	}}}
+	rendered := renderTemplate(t, TopologyTemplate, config)
+	for _, line := range []string{"10.0.0.1=us-east:rack1", `fd00\:\:2=us-west:rack2`, "default=dc1:rack1"} {
+		if !strings.Contains(rendered, line+"\n") {
+			t.Errorf("expected the line %s in\n%s", line, rendered)
+		}
+	}
+}
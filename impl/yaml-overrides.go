@@ -0,0 +1,171 @@
+package impl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables starting with this prefix override cassandra.yaml keys.
+// Nested keys are separated by a double underscore, i.e., CASSANDRA_YAML__client_encryption_options__enabled=true.
+const YamlOverrideEnvPrefix = "CASSANDRA_YAML__"
+
+// YamlOverrides are cassandra.yaml keys that are deep merged into the rendered cassandra.yaml.
+// It can be set with a yaml_overrides block in cloud.conf, CASSANDRA_YAML__ environment variables,
+// or -yaml-override key=value on the command line where nested keys are separated by dots.
+type YamlOverrides map[string]interface{}
+
+func (overrides *YamlOverrides) String() string {
+	if overrides == nil || *overrides == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", map[string]interface{}(*overrides))
+}
+
+// Set implements flag.Value for -yaml-override key=value.
+func (overrides *YamlOverrides) Set(value string) error {
+	split := strings.SplitN(value, "=", 2)
+	if len(split) != 2 || split[0] == "" {
+		return fmt.Errorf("Expected key=value but got %s", value)
+	}
+	return overrides.Put(strings.Split(split[0], "."), split[1])
+}
+
+// Put sets the override at the key path. The value is parsed as YAML so 64 is a number and [a, b] is a list.
+func (overrides *YamlOverrides) Put(path []string, value string) error {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("Unable to parse the value of %s: %s", strings.Join(path, "."), err)
+	}
+	if *overrides == nil {
+		*overrides = YamlOverrides{}
+	}
+	current := map[string]interface{}(*overrides)
+	for _, key := range path[:len(path)-1] {
+		child, ok := current[key].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			current[key] = child
+		}
+		current = child
+	}
+	current[path[len(path)-1]] = parsed
+	return nil
+}
+
+func initYamlOverrides(config *Config, logger lg.Logger) {
+	config.YamlOverrides = normalizeHclValue(map[string]interface{}(config.YamlOverrides)).(map[string]interface{})
+
+	environment := os.Environ()
+	sort.Strings(environment)
+	for _, entry := range environment {
+		if !strings.HasPrefix(entry, YamlOverrideEnvPrefix) {
+			continue
+		}
+		split := strings.SplitN(strings.TrimPrefix(entry, YamlOverrideEnvPrefix), "=", 2)
+		logger.Debug("Using", YamlOverrideEnvPrefix+split[0], "to override cassandra.yaml", "value=", split[1])
+		if err := config.YamlOverrides.Put(strings.Split(split[0], "__"), split[1]); err != nil {
+			logger.ErrorError("Unable to use "+YamlOverrideEnvPrefix+split[0], err)
+		}
+	}
+}
+
+// normalizeHclValue turns the []map[string]interface{} that HCL uses for nested blocks back into maps.
+func normalizeHclValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(typed))
+		for key, child := range typed {
+			normalized[key] = normalizeHclValue(child)
+		}
+		return normalized
+	case []map[string]interface{}:
+		merged := make(map[string]interface{})
+		for _, block := range typed {
+			for key, child := range block {
+				merged[key] = normalizeHclValue(child)
+			}
+		}
+		return merged
+	case []interface{}:
+		normalized := make([]interface{}, len(typed))
+		for index, child := range typed {
+			normalized[index] = normalizeHclValue(child)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// ApplyYamlOverrides deep merges the overrides into the YAML file. Maps are merged key by key,
+// everything else is replaced. Only the changed top level keys are rewritten so the comments,
+// blank lines and key order of the file are kept.
+func ApplyYamlOverrides(fileName string, overrides YamlOverrides, logger lg.Logger) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	file, err := loadYamlFile(fileName)
+	if err != nil {
+		logger.ErrorError("Unable to load "+fileName+" to apply yaml overrides", err)
+		return err
+	}
+
+	if err := file.merge(overrides); err != nil {
+		logger.ErrorError("Unable to apply yaml overrides to "+fileName, err)
+		return err
+	}
+
+	if err := file.write(fileName); err != nil {
+		logger.ErrorError("Unable to write yaml overrides to "+fileName, err)
+		return err
+	}
+	return nil
+}
+
+func mergeYamlMap(node *yaml.Node, overrides map[string]interface{}) error {
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := overrides[key]
+		valueNode := findYamlValue(node, key)
+
+		if childOverrides, ok := value.(map[string]interface{}); ok && valueNode != nil && valueNode.Kind == yaml.MappingNode {
+			if err := mergeYamlMap(valueNode, childOverrides); err != nil {
+				return err
+			}
+			continue
+		}
+
+		newNode := &yaml.Node{}
+		if err := newNode.Encode(value); err != nil {
+			return fmt.Errorf("Unable to encode %s: %s", key, err)
+		}
+		if valueNode != nil {
+			// Keep the comments that were attached to the old value.
+			newNode.LineComment = valueNode.LineComment
+			*valueNode = *newNode
+		} else {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, newNode)
+		}
+	}
+	return nil
+}
+
+func findYamlValue(node *yaml.Node, key string) *yaml.Node {
+	for index := 0; index+1 < len(node.Content); index += 2 {
+		if node.Content[index].Value == key {
+			return node.Content[index+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package impl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	lg "github.com/advantageous/go-logback/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Seed provider used when the stock cassandra.yaml has no seed_provider to patch.
+const SimpleSeedProvider = "org.apache.cassandra.locator.SimpleSeedProvider"
+
+// PatchYaml reads the cassandra.yaml shipped with Cassandra, sets the keys that cassandra-cloud manages and
+// writes it to outputFileName. Upstream comments, defaults and ordering of every other key are kept.
+func PatchYaml(sourceFileName string, outputFileName string, config *Config, logger lg.Logger) error {
+	if sameFile(sourceFileName, outputFileName) {
+		err := fmt.Errorf("The stock cassandra.yaml %s can't be patched in place", sourceFileName)
+		logger.ErrorError("Unable to patch cassandra.yaml", err)
+		return err
+	}
+	if err := saveStockYaml(sourceFileName, outputFileName, logger); err != nil {
+		logger.ErrorError("Unable to save the stock cassandra.yaml to "+sourceFileName, err)
+		return err
+	}
+	file, err := loadYamlFile(sourceFileName)
+	if err != nil {
+		logger.ErrorError("Unable to load the stock cassandra.yaml "+sourceFileName, err)
+		return err
+	}
+
+	maxHintsDeliveryThreads := 2
+	if config.MultiDataCenter {
+		maxHintsDeliveryThreads = 16
+	}
+
+	settings := []yamlSetting{
+		{"cluster_name", config.ClusterName},
+		{"num_tokens", config.NumTokens},
+		{"storage_port", config.ClusterPort},
+		{"ssl_storage_port", config.ClusterSslPort},
+		{"native_transport_port", config.ClientPort},
+		{"endpoint_snitch", config.Snitch},
+		{"max_hints_delivery_threads", maxHintsDeliveryThreads},
+		{"data_file_directories", config.DataDirs},
+		{"commitlog_directory", config.CommitLogDir},
+		{"concurrent_reads", yamlNumber(config.ConcurrentReads)},
+		{"concurrent_writes", yamlNumber(config.ConcurrentWrites)},
+		{"concurrent_counter_writes", yamlNumber(config.ConcurrentCounterWrites)},
+		{"concurrent_materialized_view_writes", yamlNumber(config.ConcurrentMaterializedViewWrites)},
+	}
+
+	// An address and an interface can't both be set so the one that is not used is commented out.
+	addresses := []struct {
+		address, addressKey, networkInterface, interfaceKey string
+	}{
+		{config.ClientListenAddress, "rpc_address", config.ClientListenInterface, "rpc_interface"},
+		{config.ClusterListenAddress, "listen_address", config.ClusterListenInterface, "listen_interface"},
+	}
+	for _, address := range addresses {
+		if address.address != "" {
+			settings = append(settings, yamlSetting{address.addressKey, address.address})
+			file.remove(address.interfaceKey)
+		} else if address.networkInterface != "" {
+			settings = append(settings, yamlSetting{address.interfaceKey, address.networkInterface},
+				yamlSetting{address.interfaceKey + "_prefer_ipv6", config.InterfaceIPPreference == IPPreferenceIPv6})
+			file.remove(address.addressKey)
+		}
+	}
+	if config.ClusterBroadcastAddress != "" {
+		settings = append(settings, yamlSetting{"broadcast_address", config.ClusterBroadcastAddress})
+	}
+	if config.ClientBroadcastAddress != "" {
+		settings = append(settings, yamlSetting{"broadcast_rpc_address", config.ClientBroadcastAddress})
+	}
+
+	for _, setting := range settings {
+		if err := file.set(setting.key, setting.value); err != nil {
+			logger.ErrorError("Unable to set "+setting.key, err)
+			return err
+		}
+	}
+
+	if err := file.setSeeds(config.Seeds); err != nil {
+		logger.ErrorError("Unable to set the seeds", err)
+		return err
+	}
+
+	logger.Debug("Patching", sourceFileName, "into", outputFileName)
+	if err := file.write(outputFileName); err != nil {
+		logger.ErrorError("Unable to write "+outputFileName, err)
+		return err
+	}
+	return nil
+}
+
+// yamlSetting is a top level key of cassandra.yaml and the value PatchYaml sets.
+type yamlSetting struct {
+	key   string
+	value interface{}
+}
+
+// yamlNumber keeps numbers like concurrent_reads unquoted in the YAML output.
+func yamlNumber(value string) interface{} {
+	if number, err := strconv.Atoi(value); err == nil {
+		return number
+	}
+	return value
+}
+
+// yamlFile edits the top level keys of a YAML file. Only the lines of the keys that changed are rewritten,
+// so the comments, blank lines and ordering of the rest of the file are kept as they are.
+type yamlFile struct {
+	lines    []string
+	document yaml.Node
+	root     *yaml.Node
+	indent   int
+	//First and last line (0 based) of each top level key in the original file.
+	ranges  map[string][2]int
+	changed map[string]bool
+	removed map[string]bool
+}
+
+func loadYamlFile(fileName string) (*yamlFile, error) {
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &yamlFile{
+		ranges:  make(map[string][2]int),
+		changed: make(map[string]bool),
+		removed: make(map[string]bool),
+	}
+	if err := yaml.Unmarshal(contents, &file.document); err != nil {
+		return nil, err
+	}
+	if file.document.Kind == 0 {
+		file.document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	file.root = file.document.Content[0]
+	if file.root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s is not a YAML map", fileName)
+	}
+
+	text := strings.TrimSuffix(string(contents), "\n")
+	if text != "" {
+		file.lines = strings.Split(text, "\n")
+	}
+	file.indent = detectYamlIndent(file.lines)
+	for index := 0; index+1 < len(file.root.Content); index += 2 {
+		key := file.root.Content[index]
+		start := key.Line - 1
+		file.ranges[key.Value] = [2]int{start, lastLineOfKey(file.lines, start)}
+	}
+	return file, nil
+}
+
+// lastLineOfKey finds the end of a top level key. The value continues on indented lines,
+// including indented comments, and on sequence entries that start at the first column.
+func lastLineOfKey(lines []string, start int) int {
+	last := start
+	for index := start + 1; index < len(lines); index++ {
+		line := lines[index]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "- ") || line == "-" {
+			last = index
+			continue
+		}
+		break
+	}
+	return last
+}
+
+// validateYamlSource stops patch mode from reading the file it writes, the second run would patch its own output.
+func validateYamlSource(config *Config) error {
+	if strings.ToLower(config.YamlMode) == "patch" && sameFile(config.YamlSourceFileName, config.YamlConfigFileName) {
+		return fmt.Errorf("conf_yaml_source_file and conf_yaml_file are both %s, patch mode needs the stock cassandra.yaml kept apart",
+			config.YamlConfigFileName)
+	}
+	return nil
+}
+
+func sameFile(first string, second string) bool {
+	return filepath.Clean(first) == filepath.Clean(second)
+}
+
+// saveStockYaml copies the cassandra.yaml shipped with Cassandra to the source file the first time patch mode runs.
+func saveStockYaml(sourceFileName string, outputFileName string, logger lg.Logger) error {
+	if _, err := os.Stat(sourceFileName); !os.IsNotExist(err) {
+		return nil
+	}
+	contents, err := ioutil.ReadFile(outputFileName)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Saving the stock cassandra.yaml", outputFileName, "to", sourceFileName)
+	return ioutil.WriteFile(sourceFileName, contents, 0644)
+}
+
+// detectYamlIndent uses the indentation of the first nested map, the stock cassandra.yaml uses 2 and the templates 4.
+// A file without nested maps falls back to 2 like the stock cassandra.yaml.
+func detectYamlIndent(lines []string) int {
+	for index, line := range lines {
+		if line == "" || line[0] == ' ' || line[0] == '#' || !strings.HasSuffix(strings.TrimSpace(line), ":") {
+			continue
+		}
+		for _, next := range lines[index+1:] {
+			trimmed := strings.TrimLeft(next, " ")
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if indent := len(next) - len(trimmed); indent >= 2 && indent <= 8 && !strings.HasPrefix(trimmed, "-") {
+				return indent
+			}
+			break
+		}
+	}
+	return 2
+}
+
+func (file *yamlFile) set(key string, value interface{}) error {
+	return file.merge(map[string]interface{}{key: value})
+}
+
+func (file *yamlFile) merge(overrides map[string]interface{}) error {
+	for key := range overrides {
+		file.changed[key] = true
+		delete(file.removed, key)
+	}
+	return mergeYamlMap(file.root, overrides)
+}
+
+func (file *yamlFile) remove(key string) {
+	for index := 0; index+1 < len(file.root.Content); index += 2 {
+		if file.root.Content[index].Value == key {
+			file.root.Content = append(file.root.Content[:index], file.root.Content[index+2:]...)
+			file.removed[key] = true
+			delete(file.changed, key)
+			return
+		}
+	}
+}
+
+// setSeeds only changes the seeds parameter so a custom seed provider class is kept.
+func (file *yamlFile) setSeeds(seeds string) error {
+	if seedsNode := findSeedsNode(findYamlValue(file.root, "seed_provider")); seedsNode != nil {
+		seedsNode.Value = seeds
+		seedsNode.Tag = "!!str"
+		seedsNode.Style = yaml.DoubleQuotedStyle
+		file.changed["seed_provider"] = true
+		return nil
+	}
+	return file.set("seed_provider", []interface{}{map[string]interface{}{
+		"class_name": SimpleSeedProvider,
+		"parameters": []interface{}{map[string]interface{}{"seeds": seeds}},
+	}})
+}
+
+func findSeedsNode(seedProvider *yaml.Node) *yaml.Node {
+	if seedProvider == nil || seedProvider.Kind != yaml.SequenceNode || len(seedProvider.Content) == 0 {
+		return nil
+	}
+	parameters := findYamlValue(seedProvider.Content[0], "parameters")
+	if parameters == nil || parameters.Kind != yaml.SequenceNode || len(parameters.Content) == 0 {
+		return nil
+	}
+	return findYamlValue(parameters.Content[0], "seeds")
+}
+
+// write splices the changed keys into the original lines and appends the new keys at the end.
+func (file *yamlFile) write(fileName string) error {
+	type edit struct {
+		start, end int
+		lines      []string
+	}
+	var edits []edit
+	var appended []string
+
+	for index := 0; index+1 < len(file.root.Content); index += 2 {
+		key := file.root.Content[index]
+		if !file.changed[key.Value] {
+			continue
+		}
+		lines, err := file.encodeKey(key, file.root.Content[index+1])
+		if err != nil {
+			return err
+		}
+		if lineRange, ok := file.ranges[key.Value]; ok {
+			edits = append(edits, edit{lineRange[0], lineRange[1], lines})
+		} else {
+			appended = append(appended, lines...)
+		}
+	}
+	for key := range file.removed {
+		if lineRange, ok := file.ranges[key]; ok {
+			var lines []string
+			for _, line := range file.lines[lineRange[0] : lineRange[1]+1] {
+				lines = append(lines, "# "+line)
+			}
+			edits = append(edits, edit{lineRange[0], lineRange[1], lines})
+		}
+	}
+
+	// Splice from the bottom up so the line numbers of the earlier edits stay valid.
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	lines := append([]string(nil), file.lines...)
+	for _, edit := range edits {
+		lines = append(lines[:edit.start], append(edit.lines, lines[edit.end+1:]...)...)
+	}
+	if len(appended) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, appended...)
+	}
+	output := []byte(strings.Join(lines, "\n") + "\n")
+
+	var check yaml.Node
+	if err := yaml.Unmarshal(output, &check); err != nil {
+		return fmt.Errorf("Patched YAML for %s does not parse: %s", fileName, err)
+	}
+	return ioutil.WriteFile(fileName, output, 0644)
+}
+
+// encodeKey renders one top level key. The head comment stays in the file so it is not written again.
+func (file *yamlFile) encodeKey(key *yaml.Node, value *yaml.Node) ([]string, error) {
+	keyCopy := *key
+	keyCopy.HeadComment = ""
+	keyCopy.FootComment = ""
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(file.indent)
+	if err := encoder.Encode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{&keyCopy, value}}); err != nil {
+		return nil, fmt.Errorf("Unable to encode %s: %s", key.Value, err)
+	}
+	encoder.Close()
+	return strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n"), nil
+}
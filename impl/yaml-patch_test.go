@@ -0,0 +1,178 @@
+package impl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStockYaml is a trimmed cassandra.yaml with the comments and layout of the one Cassandra ships.
+const testStockYaml = `# Cassandra storage config YAML
+
+# The name of the cluster.
+cluster_name: 'Test Cluster'
+
+num_tokens: 256
+
+# Directories where Cassandra should store data on disk.
+data_file_directories:
+- /var/lib/cassandra/data
+
+seed_provider:
+  # The seed provider class.
+  - class_name: com.example.CustomSeedProvider
+    parameters:
+      # seeds is a comma-delimited list of addresses.
+      - seeds: "127.0.0.1:7000"
+
+# Address to bind to for the other nodes.
+listen_address: localhost
+# listen_interface: eth0
+
+rpc_interface: eth1
+
+# Keep this comment.
+compaction_throughput_mb_per_sec: 16
+`
+
+func testPatchConfig() *Config {
+	return &Config{
+		ClusterName:                      "prod",
+		NumTokens:                        16,
+		ClusterPort:                      7000,
+		ClusterSslPort:                   7001,
+		ClientPort:                       9042,
+		Snitch:                           "GossipingPropertyFileSnitch",
+		DataDirs:                         []string{"/data1", "/data2"},
+		CommitLogDir:                     "/commitlog",
+		ConcurrentReads:                  "32",
+		ConcurrentWrites:                 "64",
+		ConcurrentCounterWrites:          "32",
+		ConcurrentMaterializedViewWrites: "64",
+		ClusterListenInterface:           "eth0",
+		ClientListenAddress:              "10.0.0.5",
+		ClusterBroadcastAddress:          "10.0.0.5",
+		Seeds:                            "10.0.0.1,10.0.0.2",
+	}
+}
+
+func writeStockYaml(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	source := filepath.Join(dir, "cassandra.yaml.orig")
+	if err := ioutil.WriteFile(source, []byte(testStockYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return source, filepath.Join(dir, "cassandra.yaml")
+}
+
+func TestPatchYaml(t *testing.T) {
+	source, output := writeStockYaml(t)
+	if err := PatchYaml(source, output, testPatchConfig(), testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	bytes, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched := string(bytes)
+
+	for _, expected := range []string{
+		"# Cassandra storage config YAML\n",
+		"# The name of the cluster.\ncluster_name: prod\n",
+		"num_tokens: 16\n",
+		"# Directories where Cassandra should store data on disk.\ndata_file_directories:\n  - /data1\n  - /data2\n\nseed_provider:",
+		"class_name: com.example.CustomSeedProvider",
+		`seeds: "10.0.0.1,10.0.0.2"`,
+		"# listen_address: localhost\n",
+		"listen_interface: eth0\n",
+		"listen_interface_prefer_ipv6: false\n",
+		"# rpc_interface: eth1\n",
+		"rpc_address: 10.0.0.5\n",
+		"broadcast_address: 10.0.0.5\n",
+		"concurrent_reads: 32\n",
+		"# Keep this comment.\ncompaction_throughput_mb_per_sec: 16\n",
+	} {
+		if !strings.Contains(patched, expected) {
+			t.Errorf("expected %q in the patched cassandra.yaml:\n%s", expected, patched)
+		}
+	}
+	if strings.Contains(patched, "/var/lib/cassandra/data") || strings.Contains(patched, "127.0.0.1:7000") {
+		t.Errorf("expected the stock data directory and seeds to be replaced:\n%s", patched)
+	}
+
+	// The source is kept, so patching again gives the same file.
+	if err := PatchYaml(source, output, testPatchConfig(), testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := ioutil.ReadFile(output); string(again) != patched {
+		t.Errorf("expected the second run to give the same file:\n%s", again)
+	}
+}
+
+func TestPatchYamlSavesStockYaml(t *testing.T) {
+	source, output := writeStockYaml(t)
+	if err := os.Rename(source, output); err != nil {
+		t.Fatal(err)
+	}
+	if err := PatchYaml(source, output, testPatchConfig(), testLogger()); err != nil {
+		t.Fatal(err)
+	}
+	if saved, err := ioutil.ReadFile(source); err != nil || string(saved) != testStockYaml {
+		t.Errorf("expected the stock cassandra.yaml to be saved to %s, got %v", source, err)
+	}
+}
+
+func TestPatchYamlInPlace(t *testing.T) {
+	_, output := writeStockYaml(t)
+	if err := ioutil.WriteFile(output, []byte(testStockYaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := PatchYaml(output, filepath.Join(filepath.Dir(output), ".", "cassandra.yaml"), testPatchConfig(), testLogger()); err == nil {
+		t.Error("expected an error when the source is the output")
+	}
+	config := &Config{YamlMode: "PATCH", YamlSourceFileName: output, YamlConfigFileName: output + "/."}
+	if err := validateYamlSource(config); err == nil {
+		t.Error("expected the config to be rejected when the source is the output")
+	}
+}
+
+func TestLastLineOfKey(t *testing.T) {
+	lines := strings.Split(testStockYaml, "\n")
+	tests := map[string]string{
+		"cluster_name: 'Test Cluster'": "cluster_name: 'Test Cluster'",
+		"data_file_directories:":       "- /var/lib/cassandra/data",
+		"seed_provider:":               `      - seeds: "127.0.0.1:7000"`,
+		"listen_address: localhost":    "listen_address: localhost",
+	}
+	for first, expected := range tests {
+		start := -1
+		for index, line := range lines {
+			if line == first {
+				start = index
+			}
+		}
+		if last := lines[lastLineOfKey(lines, start)]; last != expected {
+			t.Errorf("%s: expected the last line %q, got %q", first, expected, last)
+		}
+	}
+}
+
+func TestDetectYamlIndent(t *testing.T) {
+	tests := []struct {
+		yaml     string
+		expected int
+	}{
+		{"a:\n  b: 1\n", 2},
+		{"# a:\na:\n    # comment\n    b: 1\n", 4},
+		{"a:\n- 1\nb: 2\n", 2},
+		{"a: 1\n", 2},
+	}
+	for _, test := range tests {
+		if indent := detectYamlIndent(strings.Split(test.yaml, "\n")); indent != test.expected {
+			t.Errorf("%q: expected %d, got %d", test.yaml, test.expected, indent)
+		}
+	}
+}
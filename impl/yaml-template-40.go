@@ -0,0 +1,24 @@
+package impl
+
+import "strings"
+
+// YamlTemplate40 is the cassandra.yaml template for Cassandra 4.0 which no longer accepts the thrift,
+// rpc server and request scheduler settings. It is the 3.11 template without them.
+var YamlTemplate40 = yamlTemplate40Changes.Replace(YamlTemplate)
+
+// yamlTemplate40Changes removes the settings Cassandra 4.0 dropped from the 3.11 template.
+var yamlTemplate40Changes = strings.NewReplacer(
+	"thrift_prepared_statements_cache_size_mb:\n", "",
+	"start_rpc: false\nrpc_port: 9160\n", "",
+	"rpc_server_type: sync\n", "",
+	"# Frame size for thrift (maximum message length).\nthrift_framed_transport_size_in_mb: 15\n", "",
+	"# Set socket timeout for streaming operation.\n"+
+		"# The stream session is failed if no data/ack is received by any of the participants\n"+
+		"# within that period, which means this should also be sufficient to stream a large\n"+
+		"# sstable or rebuild table indexes.\n"+
+		"# Default value is 86400000ms, which means stale streams timeout after 24 hours.\n"+
+		"# A value of zero means stream sockets should never time out.\n"+
+		"# streaming_socket_timeout_in_ms: 86400000\n\n", "",
+	"request_scheduler: org.apache.cassandra.scheduler.NoScheduler\n", "",
+	"# request_scheduler_id: keyspace\n", "",
+)
@@ -0,0 +1,61 @@
+package impl
+
+import "strings"
+
+// YamlTemplate41 is the cassandra.yaml template for Cassandra 4.1 which renamed the _in_ms, _in_kb and _mb
+// settings to names that take a unit in the value, i.e., read_request_timeout: 5000ms.
+var YamlTemplate41 = yamlTemplate41Changes.Replace(YamlTemplate40)
+
+// yamlTemplate41Changes renames the settings of the 4.0 template to the 4.1 names.
+var yamlTemplate41Changes = strings.NewReplacer(
+	"# stream_throughput_outbound_megabits_per_sec: 200\n", "# stream_throughput_outbound: 24MiB/s\n",
+	"trickle_fsync_interval_in_kb: 10240\n", "trickle_fsync_interval: 10240KiB\n",
+	"max_hint_window_in_ms: 10800000 # 3 hours\n", "max_hint_window: 3h\n",
+	"hinted_handoff_throttle_in_kb: 1024\n", "hinted_handoff_throttle: 1024KiB\n",
+	"hints_flush_period_in_ms: 10000\n", "hints_flush_period: 10000ms\n",
+	"max_hints_file_size_in_mb: 128\n", "max_hints_file_size: 128MiB\n",
+	"batchlog_replay_throttle_in_kb: 1024\n", "batchlog_replay_throttle: 1024KiB\n",
+	"prepared_statements_cache_size_mb:\n", "prepared_statements_cache_size:\n",
+	"key_cache_size_in_mb:\n", "key_cache_size:\n",
+	"key_cache_save_period: 14400\n", "key_cache_save_period: 4h\n",
+	"row_cache_size_in_mb: 0\n", "row_cache_size: 0MiB\n",
+	"row_cache_save_period: 0\n", "row_cache_save_period: 0s\n",
+	"counter_cache_size_in_mb:\n", "counter_cache_size:\n",
+	"counter_cache_save_period: 7200\n", "counter_cache_save_period: 7200s\n",
+	"commitlog_sync_period_in_ms: 10000\n", "commitlog_sync_period: 10000ms\n",
+	"commitlog_segment_size_in_mb: 16\n", "commitlog_segment_size: 16MiB\n",
+	"roles_validity_in_ms: 2000\n", "roles_validity: 2000ms\n",
+	"# roles_update_interval_in_ms: 2000\n", "# roles_update_interval: 2000ms\n",
+	"permissions_validity_in_ms: 2000\n", "permissions_validity: 2000ms\n",
+	"# permissions_update_interval_in_ms: 2000\n", "# permissions_update_interval: 2000ms\n",
+	"credentials_validity_in_ms: 2000\n", "credentials_validity: 2000ms\n",
+	"# credentials_update_interval_in_ms: 2000\n", "# credentials_update_interval: 2000ms\n",
+	"index_summary_capacity_in_mb:\n", "index_summary_capacity:\n",
+	"index_summary_resize_interval_in_minutes: 60\n", "index_summary_resize_interval: 60m\n",
+	"# native_transport_max_frame_size_in_mb: 256\n", "# native_transport_max_frame_size: 16MiB\n",
+	"column_index_size_in_kb: 64\n", "column_index_size: 64KiB\n",
+	"column_index_cache_size_in_kb: 2\n", "column_index_cache_size: 2KiB\n",
+	"compaction_throughput_mb_per_sec: 16\n", "compaction_throughput: 16MiB/s\n",
+	"sstable_preemptive_open_interval_in_mb: 50\n", "sstable_preemptive_open_interval: 50MiB\n",
+	"# inter_dc_stream_throughput_outbound_megabits_per_sec: 200\n", "# inter_dc_stream_throughput_outbound: 24MiB/s\n",
+	"read_request_timeout_in_ms: 5000\n", "read_request_timeout: 5000ms\n",
+	"range_request_timeout_in_ms: 10000\n", "range_request_timeout: 10000ms\n",
+	"write_request_timeout_in_ms: 2000\n", "write_request_timeout: 2000ms\n",
+	"counter_write_request_timeout_in_ms: 5000\n", "counter_write_request_timeout: 5000ms\n",
+	"cas_contention_timeout_in_ms: 1000\n", "cas_contention_timeout: 1000ms\n",
+	"truncate_request_timeout_in_ms: 60000\n", "truncate_request_timeout: 60000ms\n",
+	"request_timeout_in_ms: 10000\n", "request_timeout: 10000ms\n",
+	"cross_node_timeout: true\n", "internode_timeout: true\n",
+	"dynamic_snitch_update_interval_in_ms: 100\n", "dynamic_snitch_update_interval: 100ms\n",
+	"dynamic_snitch_reset_interval_in_ms: 60000\n", "dynamic_snitch_reset_interval: 60000ms\n",
+	"tracetype_query_ttl: 86400\n", "trace_type_query_ttl: 1d\n",
+	"tracetype_repair_ttl: 604800\n", "trace_type_repair_ttl: 7d\n",
+	"# gc_log_threshold_in_ms: 200\n", "# gc_log_threshold: 200ms\n",
+	"enable_user_defined_functions: false\nenable_scripted_user_defined_functions: false\nwindows_timer_interval: 1\n", "user_defined_functions_enabled: false\nscripted_user_defined_functions_enabled: false\n",
+	"batch_size_warn_threshold_in_kb: 5\n", "batch_size_warn_threshold: 5KiB\n",
+	"batch_size_fail_threshold_in_kb: 50\n", "batch_size_fail_threshold: 50KiB\n",
+	"compaction_large_partition_warning_threshold_mb: 100\n", "compaction_large_partition_warning_threshold: 100MiB\n",
+	"# GC Pauses greater than gc_warn_threshold_in_ms will be logged at WARN level\n", "# GC Pauses greater than gc_warn_threshold will be logged at WARN level\n",
+	"gc_warn_threshold_in_ms: 1000\n", "gc_warn_threshold: 1000ms\n",
+	"# max_value_size_in_mb: 256\n", "# max_value_size: 256MiB\n",
+)
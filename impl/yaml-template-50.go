@@ -0,0 +1,17 @@
+package impl
+
+import "strings"
+
+// YamlTemplate50 is the cassandra.yaml template for Cassandra 5.0.
+var YamlTemplate50 = yamlTemplate50Changes.Replace(YamlTemplate41)
+
+// yamlTemplate50Changes adds storage_compatibility_mode to the 4.1 template and drops the settings 5.0 removed.
+var yamlTemplate50Changes = strings.NewReplacer(
+	"partitioner: org.apache.cassandra.dht.Murmur3Partitioner\n", "partitioner: org.apache.cassandra.dht.Murmur3Partitioner\n\n"+
+		"# CASSANDRA_4 keeps the sstable and messaging formats readable by 4.x nodes during a rolling upgrade.\n"+
+		"# Move to UPGRADING and then NONE once every node runs 5.0.\n"+
+		"storage_compatibility_mode: CASSANDRA_4\n",
+	"scripted_user_defined_functions_enabled: false\n", "",
+	"# Log a warning when compacting partitions larger than this value\n"+
+		"compaction_large_partition_warning_threshold: 100MiB\n", "",
+)
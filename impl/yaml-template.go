@@ -6,16 +6,17 @@ import (
 	lg "github.com/advantageous/go-logback/logging"
 )
 
-func initYamlTemplate(templateFileName string, logger lg.Logger) {
+func initYamlTemplate(templateFileName string, template string, logger lg.Logger) {
 	if _, err := os.Stat(templateFileName); os.IsNotExist(err) {
 		logger.Debug("Cassandra YAML template does not exist so we are creating it", templateFileName)
-		err = ioutil.WriteFile(templateFileName, []byte(YamlTemplate), 0644)
+		err = ioutil.WriteFile(templateFileName, []byte(template), 0644)
 		if err != nil {
 			logger.ErrorError("Unable to write tempalte file "+templateFileName, err)
 		}
 	}
 }
 
+// YamlTemplate is the cassandra.yaml template for Cassandra 3.11.
 const YamlTemplate = `
 
 # This file was generated with the template {{.YamlConfigTemplate}} by cassandra-cloud.
@@ -32,7 +33,8 @@ endpoint_snitch: {{.Snitch}}
 {{if .ClientListenAddress}}# Listen address for client communication
 rpc_address: {{.ClientListenAddress}}{{end}}
 {{if .ClientListenInterface}}# Listen network interface for client communication
-rpc_interface: {{.ClientListenInterface}}{{end}}
+rpc_interface: {{.ClientListenInterface}}{{if eq .InterfaceIPPreference "ipv6"}}
+rpc_interface_prefer_ipv6: true{{end}}{{end}}
 
 
 
@@ -42,13 +44,13 @@ broadcast_address: {{.ClusterBroadcastAddress}}{{end}}
 {{if .ClusterListenAddress}}# Listen address for storage cluster communication
 listen_address: {{.ClusterListenAddress}}{{end}}
 {{if .ClusterListenInterface}}# Listen network interface for storage cluster communication
-listen_interface: {{.ClusterListenInterface}}{{end}}
+listen_interface: {{.ClusterListenInterface}}{{if eq .InterfaceIPPreference "ipv6"}}
+listen_interface_prefer_ipv6: true{{end}}{{end}}
 
 {{if .MultiDataCenter}}max_hints_delivery_threads: 16{{else}}max_hints_delivery_threads: 2{{end}}
 
 
-data_file_directories:
-{{range .DataDirs}}     - {{.}}{{end}}
+data_file_directories: {{yamlList .DataDirs}}
 
 commitlog_directory: {{.CommitLogDir}}
 
@@ -56,11 +58,11 @@ commitlog_directory: {{.CommitLogDir}}
 seed_provider:
     - class_name: org.apache.cassandra.locator.SimpleSeedProvider
       parameters:
-          - seeds: "{{.ClusterSeeds}}"
+          - seeds: "{{.Seeds}}"
 
 
 
-# Use ergonomics with these
+# These use ergonomics from the data disk and CPU count unless set in cloud.conf.
 
 # For workloads with more data than can fit in memory, Cassandra's
 # bottleneck will be reads that need to fetch data from
@@ -73,9 +75,9 @@ seed_provider:
 # On the other hand, since writes are almost never IO bound, the ideal
 # number of "concurrent_writes" is dependent on the number of cores in
 # your system; (8 * number_of_cores) is a good rule of thumb.
-concurrent_reads: 32
-concurrent_writes: 32
-concurrent_counter_writes: 32
+concurrent_reads: {{.ConcurrentReads}}
+concurrent_writes: {{.ConcurrentWrites}}
+concurrent_counter_writes: {{.ConcurrentCounterWrites}}
 
 # If your data directories are backed by SSD, you should increase this
 # to the number of cores.
@@ -140,7 +142,7 @@ disk_failure_policy: stop
 commit_failure_policy: stop
 
 
-concurrent_materialized_view_writes: 32
+concurrent_materialized_view_writes: {{.ConcurrentMaterializedViewWrites}}
 memtable_allocation_type: offheap_objects
 index_summary_capacity_in_mb:
 index_summary_resize_interval_in_minutes: 60
@@ -158,7 +160,7 @@ start_native_transport: true
 # native_transport_max_concurrent_connections_per_ip: -1
 start_rpc: false
 rpc_port: 9160
-# broadcast_rpc_address: 1.2.3.4
+{{if .ClientBroadcastAddress}}broadcast_rpc_address: {{.ClientBroadcastAddress}}{{else}}# broadcast_rpc_address: 1.2.3.4{{end}}
 # enable or disable keepalive on rpc/native connections
 rpc_keepalive: true
 rpc_server_type: sync
@@ -25,8 +25,31 @@ func main() {
 	}
 
 
-	cassieConf.ProcessTemplate(config.YamlConfigTemplate, config.YamlConfigFileName, config, logger)
-	cassieConf.ProcessTemplate(config.JvmOptionsTemplate, config.JvmOptionsFileName, config, logger)
+	failed := false
+	if config.YamlMode == "patch" {
+		if err := cassieConf.PatchYaml(config.YamlSourceFileName, config.YamlConfigFileName, config, logger); err != nil {
+			logger.ErrorError("Error was", err)
+			failed = true
+		}
+	}
+	// The overrides are merged into cassandra.yaml, which is stale when the patch failed.
+	yamlFailed := failed
+	if err := cassieConf.ProcessTemplates(config, logger); err != nil {
+		logger.ErrorError("Error was", err)
+		failed = true
+	}
+	if !yamlFailed {
+		if err := cassieConf.ApplyYamlOverrides(config.YamlConfigFileName, config.YamlOverrides, logger); err != nil {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	// Only register once every file was written, other nodes use a registered node as a seed.
+	if err := cassieConf.RegisterWithConsul(config, logger); err != nil {
+		os.Exit(1)
+	}
 }
 
 func initialCommandLineParse() (bool, string, lg.Logger) {